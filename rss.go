@@ -2,7 +2,7 @@ package rss
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"time"
 )
@@ -12,20 +12,45 @@ type FeedStub struct {
 	Title string
 }
 
+// The formats a Feed can be in
+const (
+	FormatRSS  = "RSS"
+	FormatAtom = "Atom"
+	FormatJSON = "JSON Feed"
+)
+
 type Feed struct {
 	Id          int64
 	Url         string
 	Feed        string
+	Format      string // FormatRSS, FormatAtom or FormatJSON
+	Version     string // of the format, e.g. "2.0" for RSS 2.0
 	Title       string
 	Link        string
 	Subtitle    string
 	Copyright   string
-	Author      string
+	Language    string
+	Author      string // the first of Authors
+	Authors     []Person
 	PublishDate time.Time
-	Category    string
+	Category    string // the term of the first of Categories
+	Categories  []Category
 	Generator   string
 	Logo        string
 	Icon        string
+	Image       *Image      // nil if the feed has none
+	Podcast     *Podcast    // nil if the feed has no podcast elements
+	DublinCore  *DublinCore // nil if the feed has no Dublin Core elements
+	Extensions  Extensions  // what extension handlers have attached
+}
+
+// Image is the image an RSS feed gives to represent it, e.g. a logo
+type Image struct {
+	Url    string
+	Title  string
+	Link   string // where the image should link to, usually the site
+	Width  int
+	Height int
 }
 
 type Entry struct {
@@ -35,16 +60,71 @@ type Entry struct {
 	Link        string
 	Subtitle    string
 	Guid        string
-	UpdatedDate time.Time
+	Author      string // the first of Authors
+	Authors     []Person
+	Categories  []Category
+	PublishDate time.Time // as parsed, never zero: see DateSource
+	UpdatedDate time.Time // PublishDate if the feed gave no plausible update
+	FirstSeen   time.Time // when the feed was fetched
+	DateSource  string    // DatePublished, DateUpdated or DateFirstSeen
 	Summary     string
 	Encoded     string
 	Content     string
 	Source      string
 	Comments    string
+	Copyright   string
 	Thumbnail   string
-	Length      string
+	Length      string // Length, Type and Url are those of the first of Enclosures
 	Type        string
 	Url         string
+	Enclosures  []Enclosure
+	Podcast     *PodcastEpisode // nil if the entry has no podcast elements
+	Media       *Media          // nil if the entry has no Media RSS elements
+	DublinCore  *DublinCore     // nil if the entry has no Dublin Core elements
+	Extensions  Extensions      // what extension handlers have attached
+	Original    *EntryHtml      // the html as the feed gave it, if it's been sanitized
+}
+
+// EntryHtml is the html of an entry
+type EntryHtml struct {
+	Summary string
+	Encoded string
+	Content string
+}
+
+// Category is a subject a feed or entry is filed under. Scheme is the
+// vocabulary the term comes from, e.g. RSS's domain attribute, and Label
+// is the term as it should be shown, where Atom gives one.
+type Category struct {
+	Term   string
+	Scheme string
+	Label  string
+}
+
+// Person is an author or contributor
+type Person struct {
+	Name  string
+	Email string
+	URI   string
+}
+
+// String is the person's name, or failing that, their email address or URI
+func (p Person) String() string {
+	switch {
+	case p.Name != "":
+		return p.Name
+	case p.Email != "":
+		return p.Email
+	}
+	return p.URI
+}
+
+// Enclosure is a media file attached to an entry, e.g. a podcast episode.
+// Length is in bytes, and zero if unknown.
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
 }
 
 func (e *Entry) String() string {
@@ -58,11 +138,17 @@ type EntryList struct {
 
 type RssEngine struct {
 	db *RssDatabase
+
+	// Sanitizer cleans the html of the entries of feeds as they're added,
+	// as they're served as they are. DefaultSanitizer to start with; nil
+	// stores the html as the feeds give it.
+	Sanitizer *Sanitizer
 }
 
 func NewRssEngine(database, username, password string) *RssEngine {
 	rss := new(RssEngine)
 	rss.db = NewRssDatabase(database, username, password)
+	rss.Sanitizer = DefaultSanitizer()
 	return rss
 }
 
@@ -112,14 +198,14 @@ func (rss *RssEngine) AddFeedForUser(userId int64, feedUrl string) (feed *Feed,
 
 	if !feedExists {
 		// download feed
-		contents, err := rss.downloadRssFile(feedUrl)
-		fmt.Printf("Contents: %s\n", contents[0:120])
+		resp, err := rss.downloadRssFile(feedUrl)
 		if err != nil {
 			fmt.Printf("err: %v\n", err)
 			return nil, nil, err
 		}
+		defer resp.Body.Close()
 		// parse feed
-		feed, entries, err = rss.parseFeed(feedUrl, string(contents))
+		feed, entries, err = rss.parseFeed(feedUrl, resp.Body, resp.Header.Get("Content-Type"))
 		fmt.Printf("err: %v\nfeed: %v\nEntries: %v\n", err, feed, entries)
 		// store in database
 		if err != nil {
@@ -152,20 +238,27 @@ func (rss *RssEngine) AddSubscription(userId, feedId int64) (err error) {
 	return
 }
 
-func (rss *RssEngine) downloadRssFile(feedUrl string) (contents string, err error) {
-	resp, err := http.Get(feedUrl)
+// downloadRssFile fetches the feed. The caller must close the response body.
+func (rss *RssEngine) downloadRssFile(feedUrl string) (resp *http.Response, err error) {
+	resp, err = http.Get(feedUrl)
 	if err != nil {
 		fmt.Printf(err.Error())
-		return "", err
+		return nil, err
 	}
-	byteArray, e := ioutil.ReadAll(resp.Body)
-	contents = string(byteArray)
-	err = e
-	return
+	return resp, nil
 }
 
-func (rss *RssEngine) parseFeed(feedUrl, rssContents string) (feed *Feed, entries []*Entry, err error) {
-	parser := NewParser(feedUrl, rssContents)
-	feed, entries, err = parser.Parse()
+// parseFeed reads a feed in any format ParseFeed knows from rssContents
+func (rss *RssEngine) parseFeed(feedUrl string, rssContents io.Reader, contentType string) (feed *Feed, entries []*Entry, err error) {
+	data, err := io.ReadAll(rssContents)
+	if err != nil {
+		return nil, nil, err
+	}
+	feed, entries, err = ParseFeed(feedUrl, data, contentType)
+	if rss.Sanitizer != nil {
+		for _, entry := range entries {
+			rss.Sanitizer.SanitizeEntry(entry)
+		}
+	}
 	return
 }
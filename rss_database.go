@@ -23,6 +23,19 @@ type RssDatabase struct {
 	isUserSubscribedToFeedStmt *sql.Stmt
 	getFeedIdByUrlStmt         *sql.Stmt
 	getFeedByUrlStmt           *sql.Stmt
+
+	insertFeedCategoryStmt   *sql.Stmt
+	getFeedCategoriesStmt    *sql.Stmt
+	insertFeedAuthorStmt     *sql.Stmt
+	getFeedAuthorsStmt       *sql.Stmt
+	insertEntryCategoryStmt  *sql.Stmt
+	getEntryCategoriesStmt   *sql.Stmt
+	insertEntryAuthorStmt    *sql.Stmt
+	getEntryAuthorsStmt      *sql.Stmt
+	insertEntryEnclosureStmt *sql.Stmt
+	insertEntryOriginalStmt  *sql.Stmt
+	getEntryEnclosuresStmt   *sql.Stmt
+	getEntryOriginalsStmt    *sql.Stmt
 }
 
 func (rss *RssDatabase) panicOnError(err error) {
@@ -81,6 +94,54 @@ func NewRssDatabase(database, username, password string) *RssDatabase {
 	rss.panicOnError(err)
 	rss.isUserSubscribedToFeedStmt = isSubscribed
 
+	insFeedCategory, err := db.Prepare(insertFeedCategorySQL)
+	rss.panicOnError(err)
+	rss.insertFeedCategoryStmt = insFeedCategory
+
+	feedCategories, err := db.Prepare(getFeedCategoriesSQL)
+	rss.panicOnError(err)
+	rss.getFeedCategoriesStmt = feedCategories
+
+	insFeedAuthor, err := db.Prepare(insertFeedAuthorSQL)
+	rss.panicOnError(err)
+	rss.insertFeedAuthorStmt = insFeedAuthor
+
+	feedAuthors, err := db.Prepare(getFeedAuthorsSQL)
+	rss.panicOnError(err)
+	rss.getFeedAuthorsStmt = feedAuthors
+
+	insEntryCategory, err := db.Prepare(insertEntryCategorySQL)
+	rss.panicOnError(err)
+	rss.insertEntryCategoryStmt = insEntryCategory
+
+	entryCategories, err := db.Prepare(getEntryCategoriesByFeedIdSQL)
+	rss.panicOnError(err)
+	rss.getEntryCategoriesStmt = entryCategories
+
+	insEntryAuthor, err := db.Prepare(insertEntryAuthorSQL)
+	rss.panicOnError(err)
+	rss.insertEntryAuthorStmt = insEntryAuthor
+
+	entryAuthors, err := db.Prepare(getEntryAuthorsByFeedIdSQL)
+	rss.panicOnError(err)
+	rss.getEntryAuthorsStmt = entryAuthors
+
+	insEntryEnclosure, err := db.Prepare(insertEntryEnclosureSQL)
+	rss.panicOnError(err)
+	rss.insertEntryEnclosureStmt = insEntryEnclosure
+
+	entryEnclosures, err := db.Prepare(getEntryEnclosuresByFeedIdSQL)
+	rss.panicOnError(err)
+	rss.getEntryEnclosuresStmt = entryEnclosures
+
+	insEntryOriginal, err := db.Prepare(insertEntryOriginalSQL)
+	rss.panicOnError(err)
+	rss.insertEntryOriginalStmt = insEntryOriginal
+
+	entryOriginals, err := db.Prepare(getEntryOriginalsByFeedIdSQL)
+	rss.panicOnError(err)
+	rss.getEntryOriginalsStmt = entryOriginals
+
 	return rss
 }
 
@@ -120,6 +181,11 @@ func (rss *RssDatabase) getAllFeeds() (feeds []*Feed, err error) {
 		}
 		feeds = append(feeds, feed)
 	}
+	for _, feed := range feeds {
+		if err = rss.getFeedDetails(feed); err != nil {
+			return nil, err
+		}
+	}
 	return feeds, nil
 }
 
@@ -138,6 +204,11 @@ func (rss *RssDatabase) getFeedsForUser(userId int64) (feeds []*Feed, err error)
 		}
 		feeds = append(feeds, feed)
 	}
+	for _, feed := range feeds {
+		if err = rss.getFeedDetails(feed); err != nil {
+			return nil, err
+		}
+	}
 	return feeds, nil
 }
 
@@ -180,19 +251,82 @@ func (rss *RssDatabase) insertFeed(feed *Feed) (id int64, err error) {
 
 	rss.panicOnError(err)
 	id, err = res.LastInsertId()
-	return
+	if err != nil {
+		return 0, err
+	}
+
+	for _, category := range feed.Categories {
+		if _, err = rss.insertFeedCategoryStmt.Exec(id, category.Term, category.Scheme, category.Label); err != nil {
+			return 0, err
+		}
+	}
+	for _, author := range feed.Authors {
+		if _, err = rss.insertFeedAuthorStmt.Exec(id, author.Name, author.Email, author.URI); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
 }
 
 func (rss *RssDatabase) getFeedById(id uint64) (feed *Feed, err error) {
 	rows := rss.getFeedByIdStmt.QueryRow(id)
 	feed, err = getFeedFromRow(rows)
-	return
+	if err != nil {
+		return nil, err
+	}
+	return feed, rss.getFeedDetails(feed)
 }
 
 func (rss *RssDatabase) getFeedByUrl(feedUrl string) (feed *Feed, err error) {
 	rows := rss.getFeedByUrlStmt.QueryRow(feedUrl)
 	feed, err = getFeedFromRow(rows)
-	return
+	if err != nil {
+		return nil, err
+	}
+	return feed, rss.getFeedDetails(feed)
+}
+
+// getFeedDetails reads the feed's categories and authors, which are kept
+// in tables of their own
+func (rss *RssDatabase) getFeedDetails(feed *Feed) error {
+	rows, err := rss.getFeedCategoriesStmt.Query(feed.Id)
+	err = forEachRow(rows, err, func(rows *sql.Rows) error {
+		var category Category
+		if err := rows.Scan(&category.Term, &category.Scheme, &category.Label); err != nil {
+			return err
+		}
+		feed.Categories = append(feed.Categories, category)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err = rss.getFeedAuthorsStmt.Query(feed.Id)
+	return forEachRow(rows, err, func(rows *sql.Rows) error {
+		var author Person
+		if err := rows.Scan(&author.Name, &author.Email, &author.URI); err != nil {
+			return err
+		}
+		feed.Authors = append(feed.Authors, author)
+		return nil
+	})
+}
+
+// forEachRow calls fn for each of the rows a query returned, err being the
+// query's error, and closes them once done. It returns the first error,
+// whether the query's, fn's or one reading the rows.
+func forEachRow(rows *sql.Rows, err error, fn func(rows *sql.Rows) error) error {
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err = fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 type Scanner interface {
@@ -230,7 +364,10 @@ func (rss *RssDatabase) insertEntry(entry *Entry) (id int64, err error) {
 		entry.Link,
 		entry.Subtitle,
 		entry.Guid,
+		entry.PublishDate,
 		entry.UpdatedDate,
+		entry.FirstSeen,
+		entry.DateSource,
 		entry.Summary,
 		entry.Content,
 		entry.Source,
@@ -247,6 +384,27 @@ func (rss *RssDatabase) insertEntry(entry *Entry) (id int64, err error) {
 	if err != nil {
 		return 0, err
 	}
+
+	for _, category := range entry.Categories {
+		if _, err = rss.insertEntryCategoryStmt.Exec(id, category.Term, category.Scheme, category.Label); err != nil {
+			return 0, err
+		}
+	}
+	for _, author := range entry.Authors {
+		if _, err = rss.insertEntryAuthorStmt.Exec(id, author.Name, author.Email, author.URI); err != nil {
+			return 0, err
+		}
+	}
+	for _, enclosure := range entry.Enclosures {
+		if _, err = rss.insertEntryEnclosureStmt.Exec(id, enclosure.URL, enclosure.Type, enclosure.Length); err != nil {
+			return 0, err
+		}
+	}
+	if original := entry.Original; original != nil {
+		if _, err = rss.insertEntryOriginalStmt.Exec(id, original.Summary, original.Encoded, original.Content); err != nil {
+			return 0, err
+		}
+	}
 	return id, nil
 }
 
@@ -260,6 +418,9 @@ func (rss *RssDatabase) getEntriesByFeedId(feedId int64) (entries []*Entry, err
 	entries = make([]*Entry, 0, 20)
 	for rows.Next() {
 		entry := new(Entry)
+		// the date columns are null in rows stored before they were added
+		var published, updated, firstSeen sql.NullTime
+		var dateSource sql.NullString
 		err = rows.Scan(
 			&entry.Id,
 			&entry.FeedId,
@@ -267,6 +428,10 @@ func (rss *RssDatabase) getEntriesByFeedId(feedId int64) (entries []*Entry, err
 			&entry.Link,
 			&entry.Subtitle,
 			&entry.Guid,
+			&published,
+			&updated,
+			&firstSeen,
+			&dateSource,
 			&entry.Summary,
 			&entry.Content,
 			&entry.Source,
@@ -279,12 +444,90 @@ func (rss *RssDatabase) getEntriesByFeedId(feedId int64) (entries []*Entry, err
 		if err != nil {
 			return nil, err
 		}
+		entry.PublishDate, entry.UpdatedDate, entry.FirstSeen = published.Time, updated.Time, firstSeen.Time
+		entry.DateSource = dateSource.String
+		if !published.Valid && updated.Valid {
+			entry.PublishDate, entry.DateSource = updated.Time, DateUpdated
+		}
 		entries = append(entries, entry)
 	}
 
+	if err = rss.getEntryDetails(feedId, entries); err != nil {
+		return nil, err
+	}
 	return entries, nil
 }
 
+// getEntryDetails reads the categories, authors, enclosures and original
+// html of a feed's entries, a query for each rather than for each entry
+func (rss *RssDatabase) getEntryDetails(feedId int64, entries []*Entry) error {
+	byId := make(map[int64]*Entry, len(entries))
+	for _, entry := range entries {
+		byId[entry.Id] = entry
+	}
+
+	rows, err := rss.getEntryCategoriesStmt.Query(feedId)
+	err = forEachRow(rows, err, func(rows *sql.Rows) error {
+		var entryId int64
+		var category Category
+		if err := rows.Scan(&entryId, &category.Term, &category.Scheme, &category.Label); err != nil {
+			return err
+		}
+		if entry := byId[entryId]; entry != nil {
+			entry.Categories = append(entry.Categories, category)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err = rss.getEntryAuthorsStmt.Query(feedId)
+	err = forEachRow(rows, err, func(rows *sql.Rows) error {
+		var entryId int64
+		var author Person
+		if err := rows.Scan(&entryId, &author.Name, &author.Email, &author.URI); err != nil {
+			return err
+		}
+		if entry := byId[entryId]; entry != nil {
+			entry.Authors = append(entry.Authors, author)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err = rss.getEntryEnclosuresStmt.Query(feedId)
+	err = forEachRow(rows, err, func(rows *sql.Rows) error {
+		var entryId int64
+		var enclosure Enclosure
+		if err := rows.Scan(&entryId, &enclosure.URL, &enclosure.Type, &enclosure.Length); err != nil {
+			return err
+		}
+		if entry := byId[entryId]; entry != nil {
+			entry.Enclosures = append(entry.Enclosures, enclosure)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err = rss.getEntryOriginalsStmt.Query(feedId)
+	return forEachRow(rows, err, func(rows *sql.Rows) error {
+		var entryId int64
+		original := new(EntryHtml)
+		if err := rows.Scan(&entryId, &original.Summary, &original.Encoded, &original.Content); err != nil {
+			return err
+		}
+		if entry := byId[entryId]; entry != nil {
+			entry.Original = original
+		}
+		return nil
+	})
+}
+
 func (rss *RssDatabase) getFeedStatusForUser(userId int64, feedUrl string) (feedExists, subscriptionExists bool) {
 	feedExists = false
 	subscriptionExists = false
@@ -435,7 +678,10 @@ INSERT INTO rss.Entry (
   Link,
   Subtitle,
   Guid,
+  PublishDate,
   UpdatedDate,
+  FirstSeen,
+  DateSource,
   Summary,
   Content,
   Source,
@@ -459,6 +705,9 @@ INSERT INTO rss.Entry (
   ?,
   ?,
   ?,
+  ?,
+  ?,
+  ?,
   ?
 )
 ;`
@@ -471,6 +720,10 @@ SELECT
   Link,
   Subtitle,
   Guid,
+  PublishDate,
+  UpdatedDate,
+  FirstSeen,
+  DateSource,
   Summary,
   Content,
   Source,
@@ -483,6 +736,157 @@ FROM rss.Entry
 WHERE FeedId = ?
 ;`
 
+// Category, author and enclosure SQL. They're kept in the order they
+// came in the feed, which is the order of their ids.
+var insertFeedCategorySQL string = `
+INSERT INTO rss.FeedCategory (
+  FeedId,
+  Term,
+  Scheme,
+  Label
+) VALUES (
+  ?,
+  ?,
+  ?,
+  ?
+);`
+
+var getFeedCategoriesSQL string = `
+SELECT
+  Term,
+  Scheme,
+  Label
+FROM rss.FeedCategory
+WHERE FeedId = ?
+ORDER BY Id
+;`
+
+var insertFeedAuthorSQL string = `
+INSERT INTO rss.FeedAuthor (
+  FeedId,
+  Name,
+  Email,
+  URI
+) VALUES (
+  ?,
+  ?,
+  ?,
+  ?
+);`
+
+var getFeedAuthorsSQL string = `
+SELECT
+  Name,
+  Email,
+  URI
+FROM rss.FeedAuthor
+WHERE FeedId = ?
+ORDER BY Id
+;`
+
+var insertEntryCategorySQL string = `
+INSERT INTO rss.EntryCategory (
+  EntryId,
+  Term,
+  Scheme,
+  Label
+) VALUES (
+  ?,
+  ?,
+  ?,
+  ?
+);`
+
+var getEntryCategoriesByFeedIdSQL string = `
+SELECT
+  category.EntryId,
+  category.Term,
+  category.Scheme,
+  category.Label
+FROM rss.EntryCategory category
+  INNER JOIN rss.Entry entry
+    ON category.EntryId = entry.Id
+WHERE entry.FeedId = ?
+ORDER BY category.Id
+;`
+
+var insertEntryAuthorSQL string = `
+INSERT INTO rss.EntryAuthor (
+  EntryId,
+  Name,
+  Email,
+  URI
+) VALUES (
+  ?,
+  ?,
+  ?,
+  ?
+);`
+
+var getEntryAuthorsByFeedIdSQL string = `
+SELECT
+  author.EntryId,
+  author.Name,
+  author.Email,
+  author.URI
+FROM rss.EntryAuthor author
+  INNER JOIN rss.Entry entry
+    ON author.EntryId = entry.Id
+WHERE entry.FeedId = ?
+ORDER BY author.Id
+;`
+
+var insertEntryEnclosureSQL string = `
+INSERT INTO rss.EntryEnclosure (
+  EntryId,
+  URL,
+  Type,
+  Length
+) VALUES (
+  ?,
+  ?,
+  ?,
+  ?
+);`
+
+var getEntryEnclosuresByFeedIdSQL string = `
+SELECT
+  enclosure.EntryId,
+  enclosure.URL,
+  enclosure.Type,
+  enclosure.Length
+FROM rss.EntryEnclosure enclosure
+  INNER JOIN rss.Entry entry
+    ON enclosure.EntryId = entry.Id
+WHERE entry.FeedId = ?
+ORDER BY enclosure.Id
+;`
+
+var insertEntryOriginalSQL string = `
+INSERT INTO rss.EntryOriginal (
+  EntryId,
+  Summary,
+  Encoded,
+  Content
+) VALUES (
+  ?,
+  ?,
+  ?,
+  ?
+);`
+
+var getEntryOriginalsByFeedIdSQL string = `
+SELECT
+  original.EntryId,
+  original.Summary,
+  original.Encoded,
+  original.Content
+FROM rss.EntryOriginal original
+  INNER JOIN rss.Entry entry
+    ON original.EntryId = entry.Id
+WHERE entry.FeedId = ?
+;`
+
 // Subscription SQL
 
 var insertSubscriptionSQL string = `
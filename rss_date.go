@@ -0,0 +1,588 @@
+package rss
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// zoneOffsets are the time zone abbreviations feeds use, and their offsets
+// from UTC in minutes. Where an abbreviation stands for more than one zone
+// it's taken as the one feeds mean most: CST is US Central rather than
+// China, IST is India rather than Ireland or Israel, and BST is British
+// Summer Time.
+var zoneOffsets = map[string]int{
+	"UT": 0, "UTC": 0, "GMT": 0, "Z": 0, "WET": 0,
+	"WEST": 60, "BST": 60, "CET": 60, "MET": 60, "WAT": 60,
+	"CEST": 120, "MEST": 120, "EET": 120, "SAST": 120, "CAT": 120,
+	"EEST": 180, "MSK": 180, "EAT": 180, "IDT": 180,
+	"IST": 330, "PKT": 300, "ICT": 420, "WIB": 420,
+	"HKT": 480, "SGT": 480, "AWST": 480, "PHT": 480,
+	"JST": 540, "KST": 540, "ACST": 570, "ACDT": 630,
+	"AEST": 600, "AEDT": 660, "NZST": 720, "NZDT": 780,
+	"NST": -210, "NDT": -150, "AST": -240, "ADT": -180,
+	"EST": -300, "EDT": -240, "CST": -360, "CDT": -300,
+	"MST": -420, "MDT": -360, "PST": -480, "PDT": -420,
+	"AKST": -540, "AKDT": -480, "HST": -600,
+}
+
+// zoneLocations are zoneOffsets as locations, made once rather than for
+// each date
+var zoneLocations = func() map[string]*time.Location {
+	locations := make(map[string]*time.Location, len(zoneOffsets))
+	for abbr, minutes := range zoneOffsets {
+		if minutes == 0 {
+			locations[abbr] = time.UTC
+		} else {
+			locations[abbr] = time.FixedZone(abbr, minutes*60)
+		}
+	}
+	return locations
+}()
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March,
+	"apr": time.April, "may": time.May, "jun": time.June,
+	"jul": time.July, "aug": time.August, "sep": time.September,
+	"sept": time.September, "oct": time.October, "nov": time.November,
+	"dec": time.December, "january": time.January,
+	"february": time.February, "march": time.March, "april": time.April,
+	"june": time.June, "july": time.July, "august": time.August,
+	"september": time.September, "october": time.October,
+	"november": time.November, "december": time.December,
+}
+
+var weekdayNames = map[string]bool{
+	"mon": true, "tue": true, "tues": true, "wed": true, "thu": true,
+	"thur": true, "thurs": true, "fri": true, "sat": true, "sun": true,
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true,
+	"friday": true, "saturday": true, "sunday": true,
+}
+
+// words that some feeds put in their dates, which say nothing about when
+var fillerWords = map[string]bool{
+	"updated": true, "published": true, "posted": true, "at": true, "on": true,
+}
+
+// layouts that dates are reported as having, when they do, rather than
+// allocating a copy of the same
+var knownLayouts = []string{
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC3339,
+	time.RFC822,
+	time.RFC822Z,
+	time.RFC850,
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseDate parses a date in any of the formats feeds use: RFC 822 and
+// 1123 (RSS), RFC 3339 (Atom), and the many variations on them found in
+// the wild, with or without the day of the week, seconds, zone or comma,
+// with month names in full, numeric dates, 12 hour clocks and so on. Zone
+// abbreviations are read as their real offsets, e.g. PDT as -0700, and
+// unknown ones as UTC. It reads the date in one pass, rather than trying
+// layouts in turn, and returns the layout, in time.Parse's terms, that the
+// date turned out to have.
+//
+// Numeric dates are read as year first if the year's given in full there,
+// or isn't given in full at all (06-01-02), and otherwise as month first
+// (01/02/2006), unless the first number is too big to be a month or the
+// numbers are separated by dots (02.01.2006).
+func ParseDate(value string) (t time.Time, layout string, err error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return t, "", fmt.Errorf("Empty date string")
+	}
+
+	var buf [64]byte
+	p := dateParser{s: s, layout: buf[:0], year: -1, month: -1, day: -1}
+	if !p.parse() {
+		return t, "", fmt.Errorf("Could not parse date: %v", value)
+	}
+	if t, ok := p.time(); ok {
+		return t, p.layoutString(), nil
+	}
+	return t, "", fmt.Errorf("Could not parse date: %v", value)
+}
+
+// parseDate is ParseDate for when the layout doesn't matter
+func parseDate(value string) (time.Time, error) {
+	t, _, err := ParseDate(value)
+	return t, err
+}
+
+// dateParser reads a date, noting the layout it has as it goes
+type dateParser struct {
+	s      string
+	pos    int
+	layout []byte
+
+	year, month, day     int
+	twoDigitYear         bool
+	hour, minute, second int
+	nanosecond           int
+	hasTime              bool
+	hourAt, hourDigits   int // where the hour is in layout, and its digits
+	pm, am               bool
+	loc                  *time.Location
+	hasOffset            bool // loc is a numeric offset, which beats a zone name
+}
+
+func (p *dateParser) parse() bool {
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		var ok bool
+		switch {
+		case isDigit(c):
+			ok = p.number()
+		case isAsciiLetter(c):
+			ok = p.word()
+		case (c == '+' || c == '-') && (p.hasTime || p.year >= 0 && p.month >= 0 && p.day >= 0):
+			ok = p.offset()
+		case c == '-':
+			// between the parts of a date, e.g. 02-Jan-06
+			p.literal(1)
+			ok = true
+		case c == ' ' || c == '\t' || c == ',' || c == ':' || c == '/':
+			p.literal(1)
+			ok = true
+		case c == '(' && p.loc != nil:
+			ok = p.comment()
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// time is the date read, if it's a real one
+func (p *dateParser) time() (time.Time, bool) {
+	if p.year < 0 || p.month < 0 || p.day < 0 {
+		return time.Time{}, false
+	}
+	year := p.year
+	if p.twoDigitYear {
+		// as time.Parse does
+		if year >= 69 {
+			year += 1900
+		} else {
+			year += 2000
+		}
+	}
+
+	hour := p.hour
+	if p.pm || p.am {
+		if hour < 1 || hour > 12 {
+			return time.Time{}, false
+		}
+		if p.pm && hour < 12 {
+			hour += 12
+		} else if p.am && hour == 12 {
+			hour = 0
+		}
+	}
+	if p.month < 1 || p.month > 12 || p.day < 1 || p.day > daysIn(time.Month(p.month), year) ||
+		hour > 23 || p.minute > 59 || p.second > 59 {
+		return time.Time{}, false
+	}
+
+	loc := p.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Date(year, time.Month(p.month), p.day, hour, p.minute, p.second, p.nanosecond, loc), true
+}
+
+// daysIn is the number of days in month
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// layoutString is the layout read, as one of knownLayouts if it's one
+func (p *dateParser) layoutString() string {
+	for _, layout := range knownLayouts {
+		if string(p.layout) == layout {
+			return layout
+		}
+	}
+	return string(p.layout)
+}
+
+// literal copies the next n bytes, which say nothing of the date, to the
+// layout
+func (p *dateParser) literal(n int) {
+	p.layout = append(p.layout, p.s[p.pos:p.pos+n]...)
+	p.pos += n
+}
+
+// comment skips a parenthesised comment after the zone, as in +0000 (UTC),
+// which has to be the last thing in the date. It's left out of the layout,
+// along with the space before it.
+func (p *dateParser) comment() bool {
+	end := strings.IndexByte(p.s[p.pos:], ')')
+	if end < 0 || p.pos+end+1 != len(p.s) {
+		return false
+	}
+	p.pos = len(p.s)
+	p.layout = bytes.TrimRight(p.layout, " \t")
+	return true
+}
+
+func (p *dateParser) peek(offset int) byte {
+	if p.pos+offset < len(p.s) {
+		return p.s[p.pos+offset]
+	}
+	return 0
+}
+
+// digits reads a number of up to 9 digits
+func (p *dateParser) digits() (n, count int, ok bool) {
+	for p.pos < len(p.s) && isDigit(p.s[p.pos]) {
+		if count == 9 {
+			return 0, 0, false
+		}
+		n = n*10 + int(p.s[p.pos]-'0')
+		count++
+		p.pos++
+	}
+	return n, count, count > 0
+}
+
+// number reads a number, which may start a time (15:04), a numeric date
+// (2006-01-02), or be a day or year on its own
+func (p *dateParser) number() bool {
+	start := p.pos
+	n, count, ok := p.digits()
+	if !ok {
+		return false
+	}
+
+	next := p.peek(0)
+	switch {
+	case next == ':' && count <= 2 && isDigit(p.peek(1)):
+		return p.clock(n, count)
+	case (next == '-' || next == '/' || next == '.') && isDigit(p.peek(1)) && p.year < 0 && p.month < 0:
+		return p.numericDate(n, count, next)
+	case count == 4 && p.year < 0:
+		p.year = n
+		p.layout = append(p.layout, "2006"...)
+	case p.day < 0 && count <= 2 && n >= 1 && n <= 31:
+		p.day = n
+		p.layout = append(p.layout, dayLayout(count)...)
+	case p.year < 0 && count == 2:
+		p.year, p.twoDigitYear = n, true
+		p.layout = append(p.layout, "06"...)
+	case p.hasTime && n == 0:
+		// a zero offset without its sign, e.g. "15:04:05 00"
+		if p.loc == nil {
+			p.loc = time.UTC
+		}
+		p.layout = append(p.layout, p.s[start:p.pos]...)
+	default:
+		return false
+	}
+	return true
+}
+
+// clock reads a time, 15:04, 15:04:05 or 15:04:05.000, the hour of which,
+// hour, has been read
+func (p *dateParser) clock(hour, hourDigits int) bool {
+	p.hour, p.hasTime = hour, true
+	p.hourAt, p.hourDigits = len(p.layout), hourDigits
+	p.layout = append(p.layout, "15"...)
+
+	p.literal(1)
+	minute, count, ok := p.digits()
+	if !ok || count > 2 {
+		return false
+	}
+	p.minute, p.second, p.nanosecond = minute, 0, 0
+	p.layout = append(p.layout, fieldLayout("04", count)...)
+
+	if p.peek(0) != ':' || !isDigit(p.peek(1)) {
+		return true
+	}
+	p.literal(1)
+	second, count, ok := p.digits()
+	if !ok || count > 2 {
+		return false
+	}
+	p.second = second
+	p.layout = append(p.layout, fieldLayout("05", count)...)
+
+	if c := p.peek(0); (c == '.' || c == ',') && isDigit(p.peek(1)) {
+		p.literal(1)
+		fraction, count, ok := p.digits()
+		if !ok {
+			return false
+		}
+		for i := count; i < 9; i++ {
+			fraction *= 10
+		}
+		p.nanosecond = fraction
+		p.layout = append(p.layout, "000000000"[:count]...)
+	}
+	return true
+}
+
+// numericDate reads a date given as numbers, e.g. 2006-01-02 or
+// 02/01/2006, the first of which, first, has been read
+func (p *dateParser) numericDate(first, firstDigits int, sep byte) bool {
+	p.pos++
+	second, secondDigits, ok := p.digits()
+	if !ok || secondDigits > 2 || p.peek(0) != sep || !isDigit(p.peek(1)) {
+		return false
+	}
+	p.pos++
+	third, thirdDigits, ok := p.digits()
+	if !ok || thirdDigits > 4 {
+		return false
+	}
+	switch {
+	case firstDigits == 4 || thirdDigits != 4:
+		p.year, p.twoDigitYear = first, firstDigits <= 2
+		p.month, p.day = second, third
+		p.appendNumericDate(yearLayout(firstDigits), fieldLayout("01", secondDigits), dayLayout(thirdDigits), sep)
+	case sep == '.' || first > 12:
+		p.day, p.month, p.year = first, second, third
+		p.appendNumericDate(dayLayout(firstDigits), fieldLayout("01", secondDigits), "2006", sep)
+	default:
+		p.month, p.day, p.year = first, second, third
+		p.appendNumericDate(fieldLayout("01", firstDigits), dayLayout(secondDigits), "2006", sep)
+	}
+	return true
+}
+
+// appendNumericDate adds the layout of a numeric date to the layout
+func (p *dateParser) appendNumericDate(first, second, third string, sep byte) {
+	p.layout = append(p.layout, first...)
+	p.layout = append(p.layout, sep)
+	p.layout = append(p.layout, second...)
+	p.layout = append(p.layout, sep)
+	p.layout = append(p.layout, third...)
+}
+
+// word reads a day or month name, am or pm, a zone, or the T of RFC 3339
+func (p *dateParser) word() bool {
+	start := p.pos
+	for p.pos < len(p.s) && (isAsciiLetter(p.s[p.pos]) || p.s[p.pos] == '.') {
+		p.pos++
+	}
+	word := p.s[start:p.pos]
+	trimmed := strings.TrimRight(word, ".")
+	if len(trimmed) > 16 {
+		return false
+	}
+	// the word's looked up in these, rather than in a lower or upper case
+	// copy, which would allocate
+	var lower, upper [16]byte
+	for i := 0; i < len(trimmed); i++ {
+		lower[i], upper[i] = trimmed[i]|0x20, trimmed[i]&^0x20
+		if trimmed[i] == '.' {
+			lower[i], upper[i] = '.', '.'
+		}
+	}
+	name, zone := lower[:len(trimmed)], upper[:len(trimmed)]
+
+	switch {
+	case weekdayNames[string(name)]:
+		p.layout = append(p.layout, nameLayout("Mon", "Monday", name)...)
+		p.layout = append(p.layout, word[len(name):]...)
+	case monthNames[string(name)] != 0 && p.month < 0:
+		p.month = int(monthNames[string(name)])
+		p.layout = append(p.layout, nameLayout("Jan", "January", name)...)
+		p.layout = append(p.layout, word[len(name):]...)
+	case p.hasTime && (string(name) == "am" || string(name) == "pm" || string(name) == "a.m" || string(name) == "p.m"):
+		p.pm, p.am = name[0] == 'p', name[0] == 'a'
+		p.setTwelveHour()
+		if word[0] == 'P' || word[0] == 'A' {
+			p.layout = append(p.layout, "PM"...)
+		} else {
+			p.layout = append(p.layout, "pm"...)
+		}
+	case string(name) == "t" && isDigit(p.peek(0)):
+		p.layout = append(p.layout, word...)
+	case string(name) == "z" && len(word) == 1:
+		if !p.hasOffset {
+			p.loc = time.UTC
+		}
+		p.layout = append(p.layout, "Z07:00"...)
+	case fillerWords[string(name)]:
+		p.layout = append(p.layout, word...)
+	case zoneLocations[string(zone)] != nil:
+		if !p.hasOffset {
+			p.loc = zoneLocations[string(zone)]
+		}
+		p.layout = append(p.layout, "MST"...)
+	case p.hasTime && len(word) >= 2 && len(word) <= 5 && string(zone) == word:
+		// a zone we don't know, which is taken as UTC, as time.Parse does
+		if !p.hasOffset {
+			p.loc = time.FixedZone(word, 0)
+		}
+		p.layout = append(p.layout, "MST"...)
+	default:
+		return false
+	}
+	return true
+}
+
+// setTwelveHour changes the hour of the layout to a 12 hour clock's
+func (p *dateParser) setTwelveHour() {
+	hour := "03"
+	if p.hourDigits == 1 {
+		hour = "3"
+	}
+	rest := append([]byte(hour), p.layout[p.hourAt+2:]...)
+	p.layout = append(p.layout[:p.hourAt], rest...)
+}
+
+// offset reads a numeric zone offset: -0700, -07:00, -07, -700 or
+// -07:00:00. Some feeds double the sign (--0700).
+func (p *dateParser) offset() bool {
+	sign := 1
+	if p.s[p.pos] == '-' {
+		sign = -1
+	}
+	if p.peek(1) == '-' {
+		p.literal(1)
+	}
+	p.pos++
+
+	n, count, ok := p.digits()
+	if !ok || count > 4 {
+		return false
+	}
+	var hours, minutes int
+	switch count {
+	case 3, 4:
+		hours, minutes = n/100, n%100
+		p.layout = append(p.layout, "-0700"...)
+	default:
+		hours = n
+		if p.peek(0) == ':' && isDigit(p.peek(1)) {
+			p.pos++
+			if minutes, count, ok = p.digits(); !ok || count != 2 {
+				return false
+			}
+			if p.peek(0) == ':' && isDigit(p.peek(1)) {
+				// seconds, which no zone has had for a century
+				p.pos++
+				if _, count, ok = p.digits(); !ok || count != 2 {
+					return false
+				}
+				p.layout = append(p.layout, "-07:00:00"...)
+			} else {
+				p.layout = append(p.layout, "Z07:00"...)
+			}
+		} else {
+			p.layout = append(p.layout, "-07"...)
+		}
+	}
+	if hours > 14 || minutes > 59 {
+		return false
+	}
+
+	p.hasOffset = true
+	if offset := sign * (hours*60 + minutes) * 60; offset == 0 {
+		p.loc = time.UTC
+	} else {
+		p.loc = time.FixedZone("", offset)
+	}
+	return true
+}
+
+// dayLayout is the layout of a day of the month with count digits
+func dayLayout(count int) string {
+	return fieldLayout("02", count)
+}
+
+// yearLayout is the layout of a year with count digits
+func yearLayout(count int) string {
+	if count <= 2 {
+		return "06"
+	}
+	return "2006"
+}
+
+// fieldLayout is the layout of a two digit field, e.g. 01 for the month,
+// when given with count digits, which drops the zero for just one
+func fieldLayout(layout string, count int) string {
+	if count == 1 {
+		return layout[1:]
+	}
+	return layout
+}
+
+// nameLayout is the layout of a day or month name, short or long
+func nameLayout(short, long string, name []byte) string {
+	if len(name) > 4 {
+		return long
+	}
+	return short
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// Where an entry's PublishDate came from, its DateSource
+const (
+	// DatePublished is the date the feed gave as the entry's publication,
+	// e.g. RSS's <pubDate> or Atom's <published>
+	DatePublished = "published"
+
+	// DateUpdated is the date the feed gave as the entry's last update,
+	// as it gave no plausible date for its publication
+	DateUpdated = "updated"
+
+	// DateFirstSeen is when the entry was first seen, as the feed gave no
+	// plausible date for it at all
+	DateFirstSeen = "first seen"
+)
+
+// earliestDate is the earliest date an entry is taken to have: anything
+// before it, e.g. 1970 from a timestamp of zero, is a mistake
+var earliestDate = time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// futureDateTolerance is how far past the time a feed was fetched its
+// entries may be dated, for clocks that are out, before they're taken as
+// mistakes
+const futureDateTolerance = 24 * time.Hour
+
+// plausibleDate reports whether date could be a real date of an entry
+// first seen at firstSeen
+func plausibleDate(date, firstSeen time.Time) bool {
+	return !date.Before(earliestDate) && !date.After(firstSeen.Add(futureDateTolerance))
+}
+
+// checkDates sets the entry's FirstSeen to firstSeen, and makes sure it
+// has a PublishDate and an UpdatedDate that are plausible. A date that's
+// missing or implausible falls back to the other, and if both are, to when
+// the entry was first seen. DateSource says which the PublishDate is.
+func checkDates(entry *Entry, firstSeen time.Time) {
+	entry.FirstSeen = firstSeen
+	published := plausibleDate(entry.PublishDate, firstSeen)
+	updated := plausibleDate(entry.UpdatedDate, firstSeen)
+
+	switch {
+	case published:
+		entry.DateSource = DatePublished
+	case updated:
+		entry.PublishDate, entry.DateSource = entry.UpdatedDate, DateUpdated
+	default:
+		entry.PublishDate, entry.DateSource = firstSeen, DateFirstSeen
+	}
+	if !updated {
+		entry.UpdatedDate = entry.PublishDate
+	}
+}
@@ -0,0 +1,187 @@
+package rss
+
+import (
+	"strings"
+	"time"
+)
+
+// DublinCore holds the Dublin Core (dc:) elements of a feed or entry, as
+// they were given. RSS 1.0 and WordPress feeds use them in place of RSS's
+// own elements, so once the feed or entry has been read they fill in any
+// fields its own elements left empty. In order of precedence:
+//
+//	Author, Authors       <author>, <managingEditor> or Atom's <author>,
+//	                      then dc:creator, then dc:publisher
+//	PublishDate           <pubDate> or Atom's <published> (the feed's
+//	                      <updated>), then dc:date
+//	Category, Categories  <category>, then dc:subject
+//	Copyright             <copyright> or Atom's <rights>, then dc:rights
+//	Language (feed)       <language>, then dc:language
+type DublinCore struct {
+	Creators  []string
+	Date      time.Time
+	Subjects  []string
+	Rights    string
+	Language  string
+	Publisher string
+}
+
+// fillFeed fills in the feed's empty fields from its Dublin Core elements
+func (dc *DublinCore) fillFeed(feed *Feed) {
+	if len(feed.Authors) == 0 {
+		feed.Authors = dc.people()
+	}
+	if feed.Author == "" && len(feed.Authors) > 0 {
+		feed.Author = feed.Authors[0].String()
+	}
+	if feed.PublishDate.IsZero() {
+		feed.PublishDate = dc.Date
+	}
+	if len(feed.Categories) == 0 {
+		feed.Categories = dc.categories()
+	}
+	if feed.Category == "" && len(feed.Categories) > 0 {
+		feed.Category = feed.Categories[0].Term
+	}
+	if feed.Copyright == "" {
+		feed.Copyright = dc.Rights
+	}
+	if feed.Language == "" {
+		feed.Language = dc.Language
+	}
+}
+
+// fillEntry fills in the entry's empty fields from its Dublin Core elements
+func (dc *DublinCore) fillEntry(entry *Entry) {
+	if len(entry.Authors) == 0 {
+		entry.Authors = dc.people()
+	}
+	if entry.Author == "" && len(entry.Authors) > 0 {
+		entry.Author = entry.Authors[0].String()
+	}
+	if entry.PublishDate.IsZero() {
+		entry.PublishDate = dc.Date
+	}
+	if len(entry.Categories) == 0 {
+		entry.Categories = dc.categories()
+	}
+	if entry.Copyright == "" {
+		entry.Copyright = dc.Rights
+	}
+}
+
+// people are the creators, or failing them, the publisher
+func (dc *DublinCore) people() (people []Person) {
+	for _, creator := range dc.Creators {
+		people = append(people, parsePerson(creator))
+	}
+	if len(people) == 0 && dc.Publisher != "" {
+		people = append(people, parsePerson(dc.Publisher))
+	}
+	return people
+}
+
+func (dc *DublinCore) categories() (categories []Category) {
+	for _, subject := range dc.Subjects {
+		categories = append(categories, Category{Term: subject})
+	}
+	return categories
+}
+
+// extractDublinCore reads the text of a Dublin Core element into dc
+func extractDublinCore(e *Element, dc *DublinCore) {
+	text := strings.TrimSpace(e.Text())
+	if text == "" {
+		return
+	}
+
+	switch e.Name {
+	case "creator":
+		dc.Creators = append(dc.Creators, text)
+	case "date":
+		date, err := parseDate(text)
+		if err != nil {
+			e.Warnf("%v", err)
+			return
+		}
+		dc.Date = date
+	case "subject":
+		dc.Subjects = append(dc.Subjects, text)
+	case "rights":
+		dc.Rights = text
+	case "language":
+		dc.Language = text
+	case "publisher":
+		dc.Publisher = text
+	}
+}
+
+// feedDublinCore returns the feed's Dublin Core elements, adding them if
+// need be
+func feedDublinCore(feed *Feed) *DublinCore {
+	if feed.DublinCore == nil {
+		feed.DublinCore = new(DublinCore)
+	}
+	return feed.DublinCore
+}
+
+// entryDublinCore returns the entry's Dublin Core elements, adding them
+// if need be
+func entryDublinCore(entry *Entry) *DublinCore {
+	if entry.DublinCore == nil {
+		entry.DublinCore = new(DublinCore)
+	}
+	return entry.DublinCore
+}
+
+// Feed handlers
+
+func handleFeedDcCreator(e *Element, feed *Feed) {
+	extractDublinCore(e, feedDublinCore(feed))
+}
+
+func handleFeedDcDate(e *Element, feed *Feed) {
+	extractDublinCore(e, feedDublinCore(feed))
+}
+
+func handleFeedDcSubject(e *Element, feed *Feed) {
+	extractDublinCore(e, feedDublinCore(feed))
+}
+
+func handleFeedDcRights(e *Element, feed *Feed) {
+	extractDublinCore(e, feedDublinCore(feed))
+}
+
+func handleFeedDcLanguage(e *Element, feed *Feed) {
+	extractDublinCore(e, feedDublinCore(feed))
+}
+
+func handleFeedDcPublisher(e *Element, feed *Feed) {
+	extractDublinCore(e, feedDublinCore(feed))
+}
+
+// Entry handlers
+
+func handleEntryDcCreator(e *Element, entry *Entry) {
+	extractDublinCore(e, entryDublinCore(entry))
+}
+
+func handleEntryDcDate(e *Element, entry *Entry) {
+	extractDublinCore(e, entryDublinCore(entry))
+}
+
+func handleEntryDcSubject(e *Element, entry *Entry) {
+	extractDublinCore(e, entryDublinCore(entry))
+}
+
+func handleEntryDcRights(e *Element, entry *Entry) {
+	extractDublinCore(e, entryDublinCore(entry))
+}
+
+func handleEntryDcLanguage(e *Element, entry *Entry) {
+	extractDublinCore(e, entryDublinCore(entry))
+}
+
+func handleEntryDcPublisher(e *Element, entry *Entry) {
+	extractDublinCore(e, entryDublinCore(entry))
+}
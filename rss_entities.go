@@ -0,0 +1,98 @@
+package rss
+
+import (
+	"bytes"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// the entities predefined by XML
+var xmlEntities = map[string]rune{
+	"amp":  '&',
+	"lt":   '<',
+	"gt":   '>',
+	"quot": '"',
+	"apos": '\'',
+}
+
+// the longest entity name HTML defines is 31 characters
+const maxEntityLength = 32
+
+// decodeEntities replaces entity and character references (&amp;, &#38;,
+// &#x26;) with the characters they stand for. Besides the XML entities,
+// feeds routinely use HTML's (&nbsp;, &hellip;), so those are decoded too.
+// References must end in a semicolon; anything that doesn't decode is left
+// as it was written, so that a bare '&' in a URL query string survives.
+// Text without references is returned as is, without copying.
+func decodeEntities(s []byte) []byte {
+	amp := bytes.IndexByte(s, '&')
+	if amp < 0 {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s))
+	for amp >= 0 {
+		buf = append(buf, s[:amp]...)
+		s = s[amp:]
+
+		decoded, width := decodeReference(s)
+		if width == 0 {
+			buf = append(buf, '&')
+			s = s[1:]
+		} else {
+			buf = append(buf, decoded...)
+			s = s[width:]
+		}
+		amp = bytes.IndexByte(s, '&')
+	}
+	return append(buf, s...)
+}
+
+// decodeReference decodes the reference at the start of s, returning the
+// text it stands for and the number of bytes the reference took up. A
+// width of 0 means s doesn't start with a reference we understand.
+func decodeReference(s []byte) (decoded string, width int) {
+	if len(s) > maxEntityLength+2 {
+		s = s[:maxEntityLength+2]
+	}
+	end := bytes.IndexByte(s, ';')
+	if end < 2 {
+		return "", 0
+	}
+	name := s[1:end]
+
+	if name[0] != '#' {
+		if r, ok := xmlEntities[string(name)]; ok {
+			return string(r), end + 1
+		}
+		// the html package knows all of HTML's named entities. It also
+		// decodes legacy prefixes, e.g. "&ampx;" to "&x;", which we don't
+		// want, so the whole name has to have been used up.
+		ref := string(s[:end+1])
+		decoded = html.UnescapeString(ref)
+		if decoded == ref || decoded != ";" && strings.HasSuffix(decoded, ";") {
+			return "", 0
+		}
+		return decoded, end + 1
+	}
+
+	var n uint64
+	var err error
+	if len(name) > 1 && (name[1] == 'x' || name[1] == 'X') {
+		n, err = strconv.ParseUint(string(name[2:]), 16, 32)
+	} else {
+		n, err = strconv.ParseUint(string(name[1:]), 10, 32)
+	}
+	if err != nil || n == 0 || !utf8.ValidRune(rune(n)) {
+		return "", 0
+	}
+
+	// references to the C1 controls are nearly always meant as the
+	// windows-1252 characters at those code points, e.g. &#146; for ’
+	if r := rune(n); r >= 0x80 && r < 0xA0 && windows1252[r-0x80] != utf8.RuneError {
+		return string(windows1252[r-0x80]), end + 1
+	}
+	return string(rune(n)), end + 1
+}
@@ -0,0 +1,277 @@
+package rss
+
+import (
+	"bytes"
+)
+
+// FeedHandler reads an element that's a child of the feed, e.g. the
+// channel's <title>, into feed
+type FeedHandler func(e *Element, feed *Feed)
+
+// EntryHandler reads an element that's a child of an entry into entry
+type EntryHandler func(e *Element, entry *Entry)
+
+// RegisterFeedExtension sets the handler for the feed's children called
+// name in namespace, for every parser created from then on. Elements of
+// RSS itself have no namespace, "". Registering a handler for an element
+// that already has one, built in or not, replaces it, and a nil handler
+// leaves the element to be skipped. It isn't safe to call while feeds are
+// being parsed, so is best called from an init function.
+func RegisterFeedExtension(namespace, name string, handler FeedHandler) {
+	key := handlerKey(namespace, name)
+	if handler == nil {
+		delete(feedHandlers, key)
+		return
+	}
+	feedHandlers[key] = feedExtension{key, handler}
+}
+
+// RegisterEntryExtension is RegisterFeedExtension for the children of
+// entries
+func RegisterEntryExtension(namespace, name string, handler EntryHandler) {
+	key := handlerKey(namespace, name)
+	if handler == nil {
+		delete(entryHandlers, key)
+		return
+	}
+	entryHandlers[key] = entryExtension{key, handler}
+}
+
+// RegisterFeedExtension sets the handler for the feed's children called
+// name in namespace, for this parser only
+func (r *RssParser) RegisterFeedExtension(namespace, name string, handler FeedHandler) {
+	r.ownHandlerMaps()
+	key := handlerKey(namespace, name)
+	if handler == nil {
+		delete(r.feedHandlers, key)
+		return
+	}
+	r.feedHandlers[key] = feedExtension{key, handler}
+}
+
+// RegisterEntryExtension sets the handler for the children of entries
+// called name in namespace, for this parser only
+func (r *RssParser) RegisterEntryExtension(namespace, name string, handler EntryHandler) {
+	r.ownHandlerMaps()
+	key := handlerKey(namespace, name)
+	if handler == nil {
+		delete(r.entryHandlers, key)
+		return
+	}
+	r.entryHandlers[key] = entryExtension{key, handler}
+}
+
+// ownHandlerMaps copies the shared handler maps before the parser changes
+// them, so other parsers aren't affected
+func (r *RssParser) ownHandlerMaps() {
+	if r.ownHandlers {
+		return
+	}
+	feed := make(map[xmlName]feedExtension, len(r.feedHandlers))
+	for name, extension := range r.feedHandlers {
+		feed[name] = extension
+	}
+	entry := make(map[xmlName]entryExtension, len(r.entryHandlers))
+	for name, extension := range r.entryHandlers {
+		entry[name] = extension
+	}
+	r.feedHandlers, r.entryHandlers, r.ownHandlers = feed, entry, true
+}
+
+// the handlers registered for every parser
+var (
+	feedHandlers  = make(map[xmlName]feedExtension)
+	entryHandlers = make(map[xmlName]entryExtension)
+)
+
+// feedExtension and entryExtension are registered handlers, along with
+// the name they're registered under, which saves allocating the name of
+// each element handled
+type feedExtension struct {
+	name   xmlName
+	handle FeedHandler
+}
+
+type entryExtension struct {
+	name   xmlName
+	handle EntryHandler
+}
+
+// handlerKey is the name a handler is registered under, in the namespace
+// it's read as, so that e.g. a handler for Atom 0.3 handles Atom 1.0 too
+func handlerKey(namespace, name string) xmlName {
+	return xmlName{canonicalNamespace(namespace), name}
+}
+
+// Element is the element a handler has been called for, its start tag
+// having just been read. The handler reads as much of the element as it
+// needs through its methods, and the parser skips the rest. An Element is
+// only good until the handler returns.
+type Element struct {
+	Namespace string // "" for the elements of RSS itself
+	Name      string
+
+	l     *nsReader
+	base  string // what relative URLs in the element are relative to
+	attrs attributes
+	next  lexeme // the lexeme after the attributes, once they've been read
+	read  bool   // the attributes have been read
+	done  bool   // the element has been read to its end
+}
+
+// Attr is the value of the element's unprefixed attribute called name,
+// or "" if it has none
+func (e *Element) Attr(name string) string {
+	return e.AttrNS("", name)
+}
+
+// LookupAttr is Attr, also reporting whether the element has the attribute
+// at all
+func (e *Element) LookupAttr(name string) (string, bool) {
+	e.readAttributes()
+	return e.attrs.lookup(name)
+}
+
+// AttrNS is the value of the element's attribute called name in namespace
+func (e *Element) AttrNS(namespace, name string) string {
+	e.readAttributes()
+	return e.attrs[xmlName{canonicalNamespace(namespace), name}]
+}
+
+// Text reads the element's text, with entities decoded. Markup in it is
+// dropped, or in lenient mode folded into the text as html.
+func (e *Element) Text() string {
+	if e.done {
+		return ""
+	}
+	e.readAttributes()
+	e.done = true
+	if lexeme, ok := extractTextFrom(e.l, e.next); ok {
+		return string(lexeme.val)
+	}
+	return ""
+}
+
+// Html reads the element's content, text and markup alike, as html
+func (e *Element) Html() string {
+	if e.done {
+		return ""
+	}
+	e.readAttributes()
+	e.done = true
+	var buf bytes.Buffer
+	writeMarkup(e.l, &buf, e.next, false)
+	return buf.String()
+}
+
+// Xhtml reads the element's content as Html does, but without the <div>
+// that xhtml content comes wrapped in, as in Atom
+func (e *Element) Xhtml() string {
+	if e.done {
+		return ""
+	}
+	e.readAttributes()
+	e.done = true
+	return extractXhtml(e.l, e.next)
+}
+
+// Children reads the element's child elements, calling fn for each. Like
+// a handler, fn reads as much of the child as it needs, and the rest is
+// skipped.
+func (e *Element) Children(fn func(child *Element)) {
+	e.Content(nil, fn)
+}
+
+// Content reads the element's content, calling text for each piece of
+// text in it, with entities decoded, and child for each child element, as
+// Children does. Either may be nil, for content that's only one or the
+// other, e.g. RSS's <author>email (name)</author> and Atom's <author>
+// with its <name> and <email>.
+func (e *Element) Content(text func(text string), child func(child *Element)) {
+	if e.done {
+		return
+	}
+	e.readAttributes()
+	e.done = true
+	var c *Element // each child in turn, as it's only good until child returns
+	for lexeme := e.next; !lexeme.isEnd(); lexeme = e.l.nextItem() {
+		switch lexeme.typ {
+		case itemText, itemHtml:
+			if text != nil {
+				text(string(lexeme.val))
+			}
+		case itemOpenTag:
+			name := lexeme.name()
+			if c == nil {
+				c = new(Element)
+			}
+			*c = Element{Namespace: name.space, Name: name.local, l: e.l, base: e.l.base()}
+			if child != nil {
+				child(c)
+			}
+			c.Skip()
+		case itemCloseTag, itemSelfClosingTag:
+			return
+		}
+	}
+}
+
+// ResolveURL resolves ref, a URL found in the element, against the
+// element's xml:base, or failing that, the URL of the feed. Absolute URLs
+// are returned as they are.
+func (e *Element) ResolveURL(ref string) string {
+	return resolveURL(e.base, ref)
+}
+
+// ResolveHtml resolves the relative URLs in content, html found in the
+// element, as ResolveURL does
+func (e *Element) ResolveHtml(content string) string {
+	return resolveHtml(e.base, content)
+}
+
+// Warnf records a problem with the element that the handler has worked
+// around, e.g. a date that won't parse, which Parse reports in lenient
+// mode
+func (e *Element) Warnf(format string, args ...interface{}) {
+	e.l.warnf(format, args...)
+}
+
+// Skip skips whatever is left of the element
+func (e *Element) Skip() {
+	if e.done {
+		return
+	}
+	e.readAttributes()
+	e.done = true
+	skipElement(e.l, e.next)
+}
+
+func (e *Element) readAttributes() {
+	if !e.read {
+		e.attrs, e.next = readAttributes(e.l)
+		e.read = true
+	}
+}
+
+// Extensions holds whatever extension handlers attach to a feed or entry,
+// keyed on a namespace and a name, usually those of the element it was
+// read from
+type Extensions map[string]map[string]interface{}
+
+// Get is the value attached under namespace and name, or nil if there's
+// none
+func (x Extensions) Get(namespace, name string) interface{} {
+	return x[namespace][name]
+}
+
+// Set attaches value under namespace and name, replacing any value
+// already there
+func (x *Extensions) Set(namespace, name string, value interface{}) {
+	if *x == nil {
+		*x = make(Extensions)
+	}
+	if (*x)[namespace] == nil {
+		(*x)[namespace] = make(map[string]interface{})
+	}
+	(*x)[namespace][name] = value
+}
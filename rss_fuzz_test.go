@@ -0,0 +1,135 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Inputs that have hung or crashed the lexer are kept in testdata/fuzz,
+// and run along with these seeds by a plain go test.
+var fuzzSeeds = []string{
+	"",
+	"<",
+	"<a b='c' d=e f>text &amp; more</a>",
+	"<?xml version=\"1.0\" encoding=\"utf-8\"?><rss><channel></channel></rss>",
+	"<!DOCTYPE rss><rss/>",
+	"<![CDATA[ x ]]><!-- comment -->",
+	"<a:b:c x:y=\"z\"/>",
+	"<?a:b?>",
+}
+
+// lexemeLimit bounds how many lexemes an input can produce. Every lexeme
+// but the last consumes at least one byte, apart from the empty attribute
+// value of a bare attribute and the self-closing lexeme after a tag name,
+// so running past this means the lexer has stopped making progress.
+func lexemeLimit(input string) int {
+	return 2*len(input) + 2
+}
+
+// A target that hangs is left to the fuzzing engine, or in a plain go test
+// to -timeout, either of which reports the input that hung; a watchdog of
+// our own could only fail the test while the hung call carried on. An input
+// that finds new coverage is minimized for up to a minute, with no execs
+// counted meanwhile, so -fuzzminimizetime=5s keeps fuzzing moving.
+func FuzzLex(f *testing.F) {
+	for _, feed := range benchmarkFeeds {
+		f.Add(feed.content)
+	}
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		fuzzLex(t, input)
+	})
+}
+
+func fuzzLex(t *testing.T, input string) {
+	for _, lenient := range []bool{false, true} {
+		for _, l := range testLexers("fuzz", input) {
+			l.lenient = lenient
+			count := 0
+			for lexeme := l.nextItem(); !lexeme.isEnd(); lexeme = l.nextItem() {
+				if count++; count > lexemeLimit(input) {
+					t.Errorf("lexer is not making progress (lenient: %v), last lexeme: %s", lenient, lexeme)
+					return
+				}
+			}
+			if lexeme := l.nextItem(); lexeme.typ != itemEOF {
+				t.Errorf("lexer carried on after the end: %s", lexeme)
+			}
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, feed := range benchmarkFeeds {
+		f.Add(feed.content)
+	}
+	f.Add(atomContent)
+	f.Add(rss10Content)
+	f.Add(atom03Content)
+	f.Add(jsonFeedContent)
+	f.Add(podcastContent)
+	f.Add(mediaContent)
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		fuzzParse(t, input)
+	})
+}
+
+func fuzzParse(t *testing.T, input string) {
+	if feed, _, err := ParseFeed("fuzz", []byte(input), ""); feed == nil && err == nil {
+		t.Errorf("no feed, and no error from ParseFeed")
+	}
+	for _, lenient := range []bool{false, true} {
+		parsers := []*RssParser{
+			NewParser("fuzz", input),
+			NewParserFromReader("fuzz", iotest.OneByteReader(strings.NewReader(input))),
+		}
+		for _, parser := range parsers {
+			parser.SetLenient(lenient)
+			feed, _, err := parser.Parse()
+			if feed == nil && err == nil {
+				t.Errorf("no feed, and no error (lenient: %v)", lenient)
+			}
+		}
+	}
+}
+
+func FuzzSanitize(f *testing.F) {
+	f.Add(`<p onclick="x()">text <script>x()</script><a href="javascript:x()">link</a></p>`)
+	f.Add(`<img src=p.gif width=1 height=1/><b>unclosed`)
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	// text is escaped, so any of these in the output would be a tag
+	sanitizer := DefaultSanitizer()
+	f.Fuzz(func(t *testing.T, input string) {
+		output := strings.ToLower(sanitizer.Sanitize(input))
+		for _, unsafe := range []string{"<script", "<style", "<iframe"} {
+			if strings.Contains(output, unsafe) {
+				t.Errorf("sanitized %q to %q, which has %s", input, output, unsafe)
+			}
+		}
+	})
+}
+
+func FuzzParseDate(f *testing.F) {
+	for _, date := range benchmarkDates {
+		f.Add(date.date)
+	}
+	f.Add(`April 23, 2013, 5:08 p.m.`)
+	f.Add(`23/04/2013 17:08:09 --0400`)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		if date, layout, err := ParseDate(input); err == nil && (layout == "" || date.IsZero()) {
+			t.Errorf("parsed %q to %v, with the layout %q", input, date, layout)
+		}
+	})
+}
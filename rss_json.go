@@ -0,0 +1,233 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the version URLs of JSON Feed start with this
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+
+// JsonFeedParser reads a JSON Feed (https://jsonfeed.org), version 1.0
+// or 1.1, into the same Feed and Entry types as RssParser
+type JsonFeedParser struct {
+	name     string
+	reader   io.Reader
+	fetched  time.Time // when the feed was fetched, see SetFetchTime
+	lenient  bool
+	warnings LexErrorList
+}
+
+// NewJsonFeedParser creates a parser that reads a JSON Feed from r
+func NewJsonFeedParser(name string, r io.Reader) *JsonFeedParser {
+	return &JsonFeedParser{name: name, reader: r}
+}
+
+// SetFetchTime is RssParser's SetFetchTime, for JSON Feeds
+func (p *JsonFeedParser) SetFetchTime(fetched time.Time) {
+	p.fetched = fetched
+}
+
+// SetLenient is RssParser's SetLenient, for JSON Feeds. Only problems with
+// the values in a feed, e.g. dates that won't parse, are worked around;
+// JSON that's malformed is still an error. The LexErrors of JSON Feeds
+// have no line or column.
+func (p *JsonFeedParser) SetLenient(lenient bool) {
+	p.lenient = lenient
+}
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageUrl string       `json:"home_page_url"`
+	FeedUrl     string       `json:"feed_url"`
+	Description string       `json:"description"`
+	Icon        string       `json:"icon"`
+	Favicon     string       `json:"favicon"`
+	Language    string       `json:"language"`
+	Author      *jsonAuthor  `json:"author"`  // 1.0
+	Authors     []jsonAuthor `json:"authors"` // 1.1
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonItem struct {
+	Id            jsonString       `json:"id"`
+	Url           string           `json:"url"`
+	ExternalUrl   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHtml   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	Image         string           `json:"image"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Author        *jsonAuthor      `json:"author"`  // 1.0
+	Authors       []jsonAuthor     `json:"authors"` // 1.1
+	Attachments   []jsonAttachment `json:"attachments"`
+	Tags          []string         `json:"tags"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+type jsonAttachment struct {
+	Url         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	Title       string `json:"title"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+}
+
+// jsonString is a string that some feeds write as a number, e.g. an id
+type jsonString string
+
+func (s *jsonString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] != '"' {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return err
+		}
+		*s = jsonString(n)
+		return nil
+	}
+	return json.Unmarshal(data, (*string)(s))
+}
+
+func (p *JsonFeedParser) Parse() (feed *Feed, entries []*Entry, err error) {
+	if p.fetched.IsZero() {
+		p.fetched = time.Now()
+	}
+
+	var doc jsonFeed
+	if err = json.NewDecoder(p.reader).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", p.name, err)
+	}
+	if !strings.HasPrefix(doc.Version, jsonFeedVersionPrefix) {
+		return nil, nil, fmt.Errorf("%s: not a JSON Feed, version is %q", p.name, doc.Version)
+	}
+
+	feed = &Feed{
+		Format:   FormatJSON,
+		Version:  jsonFeedVersion(doc.Version),
+		Title:    doc.Title,
+		Link:     doc.HomePageUrl,
+		Subtitle: doc.Description,
+		Authors:  jsonPeople(doc.Author, doc.Authors),
+		Logo:     doc.Icon,
+		Icon:     doc.Favicon,
+		Language: doc.Language,
+	}
+	if len(feed.Authors) > 0 {
+		feed.Author = feed.Authors[0].String()
+	}
+
+	entries = make([]*Entry, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		entry := &Entry{
+			Guid:      string(item.Id),
+			Link:      item.Url,
+			Title:     item.Title,
+			Summary:   html.EscapeString(item.Summary),
+			Content:   item.ContentHtml,
+			Authors:   jsonPeople(item.Author, item.Authors),
+			Thumbnail: item.Image,
+		}
+		if entry.Link == "" {
+			entry.Link = item.ExternalUrl
+		}
+		if len(entry.Authors) == 0 {
+			// items without authors of their own are by the feed's
+			entry.Authors = append([]Person(nil), feed.Authors...)
+		}
+		if len(entry.Authors) > 0 {
+			entry.Author = entry.Authors[0].String()
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, Category{Term: tag})
+		}
+		if entry.Content == "" {
+			entry.Content = html.EscapeString(item.ContentText)
+		}
+		entry.PublishDate = p.date(item.DatePublished)
+		entry.UpdatedDate = p.date(item.DateModified)
+		for _, attachment := range item.Attachments {
+			length := ""
+			if attachment.SizeInBytes > 0 {
+				length = strconv.FormatInt(attachment.SizeInBytes, 10)
+			}
+			addEnclosure(entry, attachment.Url, attachment.MimeType, length)
+		}
+		checkDates(entry, p.fetched)
+		entries = append(entries, entry)
+	}
+	if p.lenient && len(p.warnings) > 0 {
+		return feed, entries, p.warnings
+	}
+	return feed, entries, nil
+}
+
+// jsonFeedVersion is the version number at the end of a JSON Feed's
+// version URL, where 1.0 is just "1"
+func jsonFeedVersion(url string) string {
+	version := strings.TrimPrefix(url, jsonFeedVersionPrefix)
+	if version == "1" {
+		return "1.0"
+	}
+	return version
+}
+
+// jsonPeople returns the authors, whether given in 1.1's authors array
+// or 1.0's single author
+func jsonPeople(author *jsonAuthor, authors []jsonAuthor) (people []Person) {
+	if len(authors) == 0 && author != nil {
+		authors = []jsonAuthor{*author}
+	}
+	for _, author := range authors {
+		if person := (Person{Name: author.Name, URI: author.Url}); person != (Person{}) {
+			people = append(people, person)
+		}
+	}
+	return people
+}
+
+// date parses a JSON Feed date, which should be RFC 3339, but is given
+// the same latitude as dates in XML feeds. Dates that won't parse are
+// warned of, and left zero.
+func (p *JsonFeedParser) date(date string) time.Time {
+	if date == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		if t, err = parseDate(date); err != nil && len(p.warnings) < maxWarnings {
+			p.warnings = append(p.warnings, &LexError{Name: p.name, Snippet: date, Msg: err.Error()})
+		}
+	}
+	return t
+}
+
+// isJsonFeed reports whether a feed is JSON rather than XML, going by its
+// content type (an HTTP Content-Type header) if that says, or failing that,
+// by the first byte of prefix, the start of the feed, that isn't whitespace
+func isJsonFeed(prefix []byte, contentType string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			return true
+		case strings.HasSuffix(mediaType, "/xml") || strings.HasSuffix(mediaType, "+xml"):
+			return false
+		}
+	}
+
+	prefix = bytes.TrimPrefix(prefix, []byte("\xef\xbb\xbf"))
+	prefix = bytes.TrimLeft(prefix, " \t\r\n")
+	return len(prefix) > 0 && prefix[0] == '{'
+}
@@ -1,8 +1,11 @@
 package rss
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -10,10 +13,15 @@ import (
 // http://blog.golang.org/2011/09/two-go-talks-lexical-scanning-in-go-and.html
 // http://golang.org/src/pkg/text/template/parse/lex.go
 
-// Lexeme is a parsed element
+// Lexeme is a parsed element. Its value points into the lexer's input
+// rather than being copied out of it, so it's only good for as long as
+// that input is left alone.
 type lexeme struct {
 	typ lexItemType
-	val string
+	val []byte
+	// for tag and attribute names, once resolved by an nsReader
+	prefix string
+	ns     string
 }
 
 // String() for item
@@ -24,6 +32,11 @@ func (l lexeme) String() string {
 	return fmt.Sprintf("{%s, %q}", l.typ, l.val)
 }
 
+// isEnd reports whether the lexer has nothing more to give
+func (l lexeme) isEnd() bool {
+	return l.typ == itemEOF || l.typ == itemError
+}
+
 // types of lex items
 type lexItemType int
 
@@ -32,7 +45,6 @@ const (
 	itemError
 	itemNewline
 	itemNamespace
-	itemNamespaceEnd
 	itemOpenTag
 	itemCloseTag
 	itemSelfClosingTag
@@ -40,6 +52,10 @@ const (
 	itemAttributeValue
 	itemText
 	itemHtml
+	itemDoctype      // the body of a <!DOCTYPE>, less any internal subset
+	itemDTD          // the internal DTD subset, between the brackets
+	itemProcInst     // the target of a processing instruction, e.g. "xml-stylesheet"
+	itemProcInstData // the rest of a processing instruction
 )
 
 // for pretty printing
@@ -50,7 +66,6 @@ var itemName = map[lexItemType]string{
 	itemError:          "error",
 	itemNewline:        "newline",
 	itemNamespace:      "namespace",
-	itemNamespaceEnd:   "end namespace",
 	itemOpenTag:        "open tag",
 	itemCloseTag:       "close tag",
 	itemSelfClosingTag: "self-closing tag",
@@ -58,6 +73,10 @@ var itemName = map[lexItemType]string{
 	itemAttributeValue: "attribute value",
 	itemText:           "text",
 	itemHtml:           "html",
+	itemDoctype:        "doctype",
+	itemDTD:            "dtd",
+	itemProcInst:       "processing instruction",
+	itemProcInstData:   "processing instruction data",
 }
 
 func (item lexItemType) String() string {
@@ -73,17 +92,48 @@ type stateFn func(*lexer) stateFn
 
 // lexer holds the state of the scanner
 type lexer struct {
-	name   string  // name of the input (for error reporting)
-	input  string  // the string being scanned
-	state  stateFn // next lexing function
-	pos    int     // current position in the input string
-	start  int     // start position of this item
-	width  int     // length of the last input rune
-	buffer []lexeme
+	name   string    // name of the input (for error reporting)
+	input  []byte    // the bytes being scanned (a window onto reader, if set)
+	reader io.Reader // source of further input, nil once exhausted
+	lines  int       // newlines discarded from the front of the window
+	column int       // runes discarded since the last discarded newline
+	offset int       // bytes discarded from the front of the window
+	err    error     // the first error encountered, if any
+	state  stateFn   // next lexing function
+	pos    int       // current position in the input string
+	start  int       // start position of this item
+	width  int       // length of the last input rune
+	buffer lexemeQueue
+
+	// reads in a row that returned neither data nor an error
+	emptyReads int
+
+	// the line and column of the last position reported, which the next
+	// is counted on from
+	counted position
+
+	// in lenient mode, errors are collected as warnings and lexing
+	// picks up again at the next tag
+	lenient  bool
+	warnings LexErrorList
+
+	// keepSpace keeps the whitespace between tags, which matters in html
+	keepSpace bool
+
+	// html reads the content of rawTextElements as text. rawText is the
+	// close tag (e.g. "</script") that ends the one just opened, if it is.
+	html    bool
+	rawText string
 }
 
 // create a new lexer
 func lex(name, input string) *lexer {
+	return lexBytes(name, []byte(input))
+}
+
+// create a new lexer over input, which must not be modified while the
+// lexer, or any lexeme it returns, is still in use
+func lexBytes(name string, input []byte) *lexer {
 	l := &lexer{
 		name:  name,
 		input: input,
@@ -92,16 +142,115 @@ func lex(name, input string) *lexer {
 	return l
 }
 
+// create a new lexer over content, html, which is lenient, keeps the
+// whitespace between tags, and reads the content of scripts and the like
+// as text rather than markup
+func lexHtml(content string) *lexer {
+	l := lex("html", content)
+	l.lenient = true
+	l.keepSpace = true
+	l.html = true
+	return l
+}
+
+// the html elements whose content is text up to their close tag, however
+// much it looks like markup. Those marked true still have entities in it.
+var rawTextElements = map[string]bool{
+	"script": false, "style": false, "xmp": false, "iframe": false,
+	"noembed": false, "noframes": false, "noscript": false,
+	"title": true, "textarea": true,
+}
+
+// create a new lexer that pulls its input from r in chunks, rather
+// than requiring the whole document up front
+func lexReader(name string, r io.Reader) *lexer {
+	l := &lexer{
+		name:   name,
+		reader: r,
+		state:  lexContentStart,
+	}
+	return l
+}
+
 // represent EOF when we're parsing an input string
 const eof = -1
 
-// next returns the next rune in the string
+// how much we ask the reader for at a time
+const readChunkSize = 4096
+
+// how many reads in a row may return neither data nor an error before
+// we give up on the reader, as bufio does
+const maxEmptyReads = 100
+
+// fill appends the next chunk from the reader to the input window. When
+// the window is full, the current item is moved to a fresh one and
+// everything before it is dropped; positions are shifted so that pos and
+// start still refer to the same runes. The old window is never written
+// to again, as lexemes already handed out point into it. Returns false
+// once the reader is exhausted.
+func (l *lexer) fill() bool {
+	if l.reader == nil {
+		return false
+	}
+
+	if cap(l.input)-len(l.input) < readChunkSize/4 {
+		// an item larger than the chunk size means we grow the window,
+		// so that we don't re-copy a long pending item for every chunk
+		pending := l.input[l.start:]
+		window := make([]byte, len(pending), readChunkSize+2*len(pending))
+		copy(window, pending)
+
+		discarded := l.input[:l.start]
+		l.lines += bytes.Count(discarded, []byte("\n"))
+		if i := bytes.LastIndexByte(discarded, '\n'); i >= 0 {
+			l.column = utf8.RuneCount(discarded[i+1:])
+		} else {
+			l.column += utf8.RuneCount(discarded)
+		}
+		if l.counted.pos >= l.start {
+			l.counted.pos -= l.start
+		} else {
+			l.counted = position{line: l.lines, column: l.column}
+		}
+		l.offset += l.start
+		l.input = window
+		l.pos -= l.start
+		l.start = 0
+	}
+
+	n, err := l.reader.Read(l.input[len(l.input):cap(l.input)])
+	l.input = l.input[:len(l.input)+n]
+	if n > 0 || err != nil {
+		l.emptyReads = 0
+	} else if l.emptyReads++; l.emptyReads >= maxEmptyReads {
+		err = io.ErrNoProgress
+	}
+	if err != nil {
+		l.reader = nil
+		if err != io.EOF {
+			l.fail(l.newError(fmt.Sprintf("read failed: %v", err)))
+		}
+	}
+	return true
+}
+
+// next returns the next rune in the input
 func (l *lexer) next() (r rune) {
+	if l.pos < len(l.input) && l.input[l.pos] < utf8.RuneSelf {
+		l.width = 1
+		l.pos++
+		return rune(l.input[l.pos-1])
+	}
+	for l.pos >= len(l.input) || !utf8.FullRune(l.input[l.pos:]) {
+		if !l.fill() {
+			break
+		}
+	}
 	if l.pos >= len(l.input) {
 		l.width = 0
 		return eof
 	}
-	r, l.width = utf8.DecodeRuneInString(l.input[l.pos:])
+	r, l.width = utf8.DecodeRune(l.input[l.pos:])
 	l.pos += l.width
 	return r
 }
@@ -119,27 +268,89 @@ func (l *lexer) peek() (r rune) {
 }
 
 func (l *lexer) peekForward(amnt int) (r rune) {
-	for i := 0; i < amnt; i++ {
-		r = l.next()
-		if r == eof {
-			l.errorf("peeked past end of content")
-			return eof
-		}
+	r = l.lookAhead(amnt)
+	if r == eof && !l.lenient {
+		l.errorf("peeked past end of content")
 	}
-	for i := 0; i < amnt; i++ {
-		l.backup()
+	return r
+}
+
+// lookAhead returns the amnt'th rune from the current position, or eof
+// if the input runs out first, without consuming anything
+func (l *lexer) lookAhead(amnt int) (r rune) {
+	// fill may move the window, so remember where we were relative to start
+	offset, width := l.pos-l.start, l.width
+	for i := 0; i < amnt && r != eof; i++ {
+		r = l.next()
 	}
+	l.pos, l.width = l.start+offset, width
 	return r
 }
 
+// atTagStart reports whether the '<' at the current position begins
+// markup, rather than being a stray character in text
+func (l *lexer) atTagStart() bool {
+	if l.peek() != '<' {
+		return false
+	}
+	r := l.lookAhead(2)
+	return r == '/' || r == '!' || r == '?' || r == '_' || r == ':' || unicode.IsLetter(r)
+}
+
 // emit an item back to the client
 func (l *lexer) emit(t lexItemType) {
-	l.buffer = append(l.buffer, lexeme{t, l.previewCurrent()})
+	l.buffer.push(lexeme{typ: t, val: l.previewCurrent()})
 	l.start = l.pos
 }
 
-func (l *lexer) previewCurrent() string {
-	return l.input[l.start:l.pos]
+// emit an item without any trailing whitespace
+func (l *lexer) emitTrimmed(t lexItemType) {
+	l.buffer.push(lexeme{typ: t, val: bytes.TrimRight(l.previewCurrent(), " \t\r\n")})
+	l.start = l.pos
+}
+
+// emit an item with entity and character references decoded
+func (l *lexer) emitDecoded(t lexItemType) {
+	l.buffer.push(lexeme{typ: t, val: decodeEntities(l.previewCurrent())})
+	l.start = l.pos
+}
+
+// previewCurrent returns the pending item. Its capacity is clipped, so
+// that appending to it can't write over the input.
+func (l *lexer) previewCurrent() []byte {
+	return l.input[l.start:l.pos:l.pos]
+}
+
+// lexemeQueue holds emitted lexemes until they're asked for. It reuses
+// its storage once drained, rather than allocating as it goes.
+type lexemeQueue struct {
+	items []lexeme
+	head  int
+}
+
+func (q *lexemeQueue) push(item lexeme) {
+	q.items = append(q.items, item)
+}
+
+// pop removes and returns the oldest lexeme, if there is one
+func (q *lexemeQueue) pop() (item lexeme, ok bool) {
+	if q.head == len(q.items) {
+		return item, false
+	}
+	item = q.items[q.head]
+	q.head++
+	if q.head == len(q.items) {
+		q.items, q.head = q.items[:0], 0
+	}
+	return item, true
+}
+
+func (q *lexemeQueue) len() int {
+	return len(q.items) - q.head
+}
+
+func (q *lexemeQueue) clear() {
+	q.items, q.head = q.items[:0], 0
 }
 
 // skips the pending input
@@ -163,20 +374,49 @@ func (l *lexer) acceptRun(validRunes string) {
 	l.backup()
 }
 
-// accepts a series of runes that are not in the invalidRunes characters
+// accepts a series of runes that are not in the invalidRunes characters,
+// which must all be ASCII. As no byte of a multi-byte rune is ASCII, the
+// input can be searched a byte at a time.
 func (l *lexer) acceptRunUntil(invalidRunes string) {
 	for {
-		rune := l.next()
-		if rune == eof {
+		if i := bytes.IndexAny(l.input[l.pos:], invalidRunes); i >= 0 {
+			l.pos += i
 			break
 		}
+		l.pos = len(l.input)
+		if !l.fill() {
+			break
+		}
+	}
+	l.width = 0
+}
 
-		isFound := strings.IndexRune(invalidRunes, rune)
-		if isFound >= 0 {
+// hasPrefix reports whether the input at the current position starts with
+// prefix, ignoring ASCII case as HTML does for keywords like DOCTYPE
+func (l *lexer) hasPrefix(prefix string) bool {
+	for len(l.input)-l.pos < len(prefix) {
+		if !l.fill() {
 			break
 		}
 	}
-	l.backup()
+	rest := l.input[l.pos:]
+	return len(rest) >= len(prefix) && bytes.EqualFold(rest[:len(prefix)], []byte(prefix))
+}
+
+// acceptPast accepts everything up to and including the next occurrence
+// of delim, returning false if the input runs out first
+func (l *lexer) acceptPast(delim string) bool {
+	for {
+		l.acceptRunUntil(delim[:1])
+		if l.peek() == eof {
+			return false
+		}
+		if l.hasPrefix(delim) {
+			l.pos += len(delim)
+			return true
+		}
+		l.next()
+	}
 }
 
 // skips all spaces and tabs from the current position
@@ -187,40 +427,186 @@ func (l *lexer) skipWhitespace() {
 
 // which line are we currently on?
 func (l *lexer) lineNumber() int {
-	return 1 + strings.Count(l.input[:l.pos], "\n")
+	line, _ := l.position()
+	return line
+}
+
+// position is a place in the input, and the line and column (in runes)
+// it's at, both counted from zero
+type position struct {
+	pos    int
+	line   int
+	column int
+}
+
+// position returns the line and column, counted from one, that the lexer
+// is on. They're counted on from the last position asked for, rather than
+// from the start of the input, so that reporting a problem doesn't cost
+// more the further into the input it is.
+func (l *lexer) position() (line, column int) {
+	if l.pos < l.counted.pos {
+		// backed up since: count back, unless that means finding the
+		// start of the line
+		behind := l.input[l.pos:l.counted.pos]
+		if bytes.IndexByte(behind, '\n') < 0 {
+			return 1 + l.counted.line, 1 + l.counted.column - utf8.RuneCount(behind)
+		}
+		l.counted = position{line: l.lines, column: l.column}
+	}
+
+	counting := l.input[l.counted.pos:l.pos]
+	if i := bytes.LastIndexByte(counting, '\n'); i >= 0 {
+		l.counted.line += bytes.Count(counting, []byte("\n"))
+		l.counted.column = utf8.RuneCount(counting[i+1:])
+	} else {
+		l.counted.column += utf8.RuneCount(counting)
+	}
+	l.counted.pos = l.pos
+	return 1 + l.counted.line, 1 + l.counted.column
+}
+
+// snippet returns the input surrounding the current position, for
+// showing alongside errors
+func (l *lexer) snippet() string {
+	from, to := l.start, l.pos+20
+	if l.pos-from > 40 {
+		from = l.pos - 40
+	}
+	if to > len(l.input) {
+		to = len(l.input)
+	}
+	return string(bytes.ToValidUTF8(l.input[from:to], nil))
+}
+
+// LexError reports where, and why, the lexer gave up on its input.
+// In lenient mode, it reports a problem the lexer recovered from.
+type LexError struct {
+	Name    string // name of the input
+	Line    int
+	Column  int
+	Snippet string // the input around the problem
+	Msg     string
+}
+
+func (e *LexError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s, near %q", e.Name, e.Line, e.Column, e.Msg, e.Snippet)
+}
+
+// LexErrorList is the set of problems found in lenient mode
+type LexErrorList []*LexError
+
+func (list LexErrorList) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", list[0], len(list)-1)
+}
+
+func (l *lexer) newError(msg string) *LexError {
+	line, column := l.position()
+	return &LexError{
+		Name:    l.name,
+		Line:    line,
+		Column:  column,
+		Snippet: l.snippet(),
+		Msg:     msg,
+	}
+}
+
+// maxWarnings is how many warnings are kept. Past it, there's one more
+// saying there were too many, and the rest are dropped.
+const maxWarnings = 100
+
+// warn records a warning, unless there have been too many
+func (l *lexer) warn(msg string) {
+	switch {
+	case len(l.warnings) < maxWarnings:
+		l.warnings = append(l.warnings, l.newError(msg))
+	case len(l.warnings) == maxWarnings:
+		l.warnings = append(l.warnings, l.newError("too many errors"))
+	}
 }
 
 // error returns an error token and terminates the scan by passing
 // back a nil pointer that will be the next state, terminating l.nextItem.
+// In lenient mode the error is recorded as a warning instead, and the
+// scan resumes at the next tag.
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.buffer = append(l.buffer, lexeme{itemError, fmt.Sprintf(format, args...)})
+	if l.lenient {
+		if len(l.warnings) <= maxWarnings {
+			l.warn(fmt.Sprintf(format, args...))
+		}
+		return lexResync
+	}
+	l.fail(l.newError(fmt.Sprintf(format, args...)))
 	return nil
 }
 
-// nextItem returns the next item from the input
+// warnf records a problem that has been worked around. Only lenient mode
+// reports them.
+func (l *lexer) warnf(format string, args ...interface{}) {
+	if len(l.warnings) <= maxWarnings {
+		l.warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// fail emits an error token. Only the first error is kept, as later ones
+// tend to be fallout from it.
+func (l *lexer) fail(err *LexError) {
+	if l.err == nil {
+		l.err = err
+	}
+	l.buffer.push(lexeme{typ: itemError, val: []byte(err.Msg)})
+}
+
+// the most state functions that can run in a row without consuming any
+// input or emitting anything, e.g. lexContentStart to lexTagStart
+const maxStalls = 8
+
+// nextItem returns the next item from the input. Once an error or the
+// end of the input has been returned, every later call returns itemEOF.
+// Should a state function ever stop making progress, lexing ends with an
+// error rather than looping forever.
 func (l *lexer) nextItem() lexeme {
+	mark, stalls := -1, 0
 	for {
-		if len(l.buffer) > 0 {
-			lexeme := l.buffer[0]
-			l.buffer = l.buffer[1:]
-			if lexeme.typ == itemError {
-				panic("ERROR: " + lexeme.val + "\n")
+		if item, ok := l.buffer.pop(); ok {
+			if item.typ == itemError || item.typ == itemEOF {
+				l.buffer.clear()
+				l.state = nil
 			}
-			return lexeme
+			return item
+		} else if l.state == nil {
+			return lexeme{typ: itemEOF}
 		} else {
 			l.state = l.state(l)
+			if l.offset+l.pos != mark {
+				mark, stalls = l.offset+l.pos, 0
+			} else if stalls++; stalls > maxStalls {
+				l.state = nil
+				l.fail(l.newError("lexer is stuck"))
+			}
 		}
 	}
-	panic("Not reached")
 }
 
 func lexContentStart(l *lexer) stateFn {
-	l.skipWhitespace()
+	if l.keepSpace {
+		l.ignore()
+	} else {
+		l.skipWhitespace()
+	}
 	switch l.peek() {
 	case eof:
 		l.emit(itemEOF)
 		return nil
 	case '<':
+		if l.lenient && !l.atTagStart() {
+			return lexTagContents
+		}
 		return lexTagStart
 	default:
 		return lexTagContents
@@ -230,6 +616,20 @@ func lexContentStart(l *lexer) stateFn {
 	return nil
 }
 
+// lexResync skips past whatever upset the lexer, to the next tag
+func lexResync(l *lexer) stateFn {
+	l.next()
+	for {
+		l.acceptRunUntil("<")
+		if l.peek() == eof || l.atTagStart() {
+			break
+		}
+		l.accept("<")
+	}
+	l.ignore()
+	return lexContentStart
+}
+
 func lexTagStart(l *lexer) stateFn {
 	isClosingTag := false
 
@@ -237,10 +637,16 @@ func lexTagStart(l *lexer) stateFn {
 	switch l.peek() {
 	case '!':
 		l.backup()
+		switch {
+		case l.hasPrefix("<!--"):
+			return lexComment
+		case l.hasPrefix("<!DOCTYPE"):
+			return lexDoctype
+		}
 		return lexCData
 	case '?':
 		l.backup()
-		return lexNamespace
+		return lexProcInst
 	case '/':
 		l.next()
 		isClosingTag = true
@@ -257,8 +663,15 @@ func lexTagStart(l *lexer) stateFn {
 			l.emit(itemNamespace) // namespace, just loop around and keep parsing
 			l.accept(":")
 			l.ignore()
-		case ' ':
-			l.emit(itemOpenTag)
+		case ' ', '\t', '\r', '\n':
+			if isClosingTag {
+				l.emit(itemCloseTag)
+				l.skipWhitespace()
+				l.accept(">")
+				l.ignore()
+				return lexContentStart
+			}
+			l.emitOpenTag()
 			return lexAttributes
 		case '>':
 			if isClosingTag {
@@ -266,23 +679,24 @@ func lexTagStart(l *lexer) stateFn {
 				l.accept(">")
 				return lexContentStart
 			} else {
-				l.emit(itemOpenTag)
+				l.emitOpenTag()
 				l.accept(">")
-				return lexTagContents
+				return l.startTagEnd()
 			}
 		case '/':
+			if l.pos > l.start {
+				l.emitOpenTag()
+			}
 			l.accept("/")
-			if l.peek() == '>' {
-				l.acceptRun("/>")
-				l.ignore()
-				l.emit(itemSelfClosingTag)
-				return lexContentStart
-			} else {
-				l.backup()
+			if l.peek() != '>' {
+				return l.errorf("error parsing tag, unexpected symbol: '/'")
 			}
+			l.accept(">")
+			l.ignore()
+			l.emit(itemSelfClosingTag)
+			return lexContentStart
 		default:
-			l.errorf("error parsing tag, unexpected symbol: %v", l.peek())
-			return nil
+			return l.errorf("error parsing tag, unexpected symbol: %q", l.peek())
 		}
 	}
 
@@ -290,64 +704,91 @@ func lexTagStart(l *lexer) stateFn {
 	return nil
 }
 
+// emitOpenTag emits the name of an open tag, noting in html whether it's
+// one whose content is raw text
+func (l *lexer) emitOpenTag() {
+	l.rawText = ""
+	if l.html {
+		tag := strings.ToLower(string(l.previewCurrent()))
+		if _, ok := rawTextElements[tag]; ok {
+			l.rawText = "</" + tag
+		}
+	}
+	l.emit(itemOpenTag)
+}
+
+// startTagEnd is the state after the '>' that ends a start tag
+func (l *lexer) startTagEnd() stateFn {
+	if l.rawText != "" {
+		return lexRawText
+	}
+	return lexTagContents
+}
+
 func lexAttributes(l *lexer) stateFn {
 	l.skipWhitespace()
 
 	// check our breakout conditions
 	switch l.peek() {
 	case '/':
-		l.accept("/")
-		if l.peek() == '>' {
-			l.accept(">")
+		if l.hasPrefix("/>") {
+			l.pos += len("/>")
 			l.ignore()
 			l.emit(itemSelfClosingTag)
 			return lexContentStart
-		} else {
-			l.backup()
 		}
-	case '?', '!':
-		l.acceptRun("!?>")
-		l.ignore()
-		l.emit(itemNamespaceEnd)
-		return lexTagContents
 	case '>':
 		l.accept(">")
 		l.ignore()
-		return lexTagContents
+		return l.startTagEnd()
+	case eof:
+		return l.errorf("tag is never closed")
 	}
 
-Loop:
-	// OK, we should have more attributes, so parse them out
+	// the attribute name, which may be namespaced
 	for {
-		l.acceptRunUntil(":=")
-		switch l.peek() {
-		case ':':
-			l.emit(itemNamespace)
-			l.accept(":")
-			l.ignore()
-		case '=':
-			l.emit(itemAttributeName)
-			break Loop
-		case eof:
-			l.errorf("lex attributes: did not find :=")
-			return nil
-		default:
-			fmt.Printf("Stuck in loop? %c\n", l.previewCurrent())
+		l.acceptRunUntil(":= \t\r\n/>?!")
+		if l.peek() != ':' {
+			break
 		}
+		l.emit(itemNamespace)
+		l.accept(":")
+		l.ignore()
+	}
+	if l.pos == l.start {
+		return l.errorf("lex attributes: unexpected symbol: %q", l.peek())
+	}
+	l.emit(itemAttributeName)
+
+	l.skipWhitespace()
+	if !l.accept("=") {
+		// a bare attribute, e.g. <option selected>
+		l.emit(itemAttributeValue)
+		return lexAttributes
+	}
+	l.skipWhitespace()
+
+	switch quote := l.peek(); quote {
+	case '"', '\'':
+		l.next()
+		l.ignore()
+		l.acceptRunUntil(string(quote))
+		if l.peek() == eof {
+			return l.errorf("attribute value is never closed")
+		}
+		l.emitDecoded(itemAttributeValue)
+		l.accept(string(quote))
+		l.ignore()
+	default:
+		// unquoted values run until whitespace or the end of the tag
+		l.acceptRunUntil(" \t\r\n>")
+		l.emitDecoded(itemAttributeValue)
 	}
-	l.acceptRun("=\"")
-	l.ignore()
-	l.acceptRunUntil("\"")
-	l.emit(itemAttributeValue)
-	l.accept("\"")
 
 	return lexAttributes
 }
 
 func lexCData(l *lexer) stateFn {
-	if l.peek() == '<' && l.peekForward(2) == '!' && l.peekForward(3) == '-' && l.peekForward(4) == '-' {
-		return lexComment
-	}
 	l.acceptRun("<![")
 	// skip CDATA
 	l.acceptRunUntil("[")
@@ -361,8 +802,13 @@ Loop:
 		if l.peek() == ']' && l.peekForward(2) == ']' && l.peekForward(3) == '>' {
 			break Loop
 		} else if l.peek() == eof {
-			l.errorf("CDATA EOF reached")
-			return nil
+			if l.lenient {
+				// read the section as ordinary content instead
+				l.pos = l.start
+				l.warnf("CDATA section is never closed")
+				return lexTagContents
+			}
+			return l.errorf("CDATA EOF reached")
 		}
 		l.accept("]")
 	}
@@ -379,6 +825,17 @@ func lexComment(l *lexer) stateFn {
 Loop:
 	for {
 		l.acceptRunUntil("-")
+		if l.peek() == eof {
+			if l.lenient {
+				// read what follows the comment opener as ordinary content
+				l.pos = l.start
+				l.warnf("comment is never closed")
+				l.acceptRun("<!-")
+				l.ignore()
+				return lexTagContents
+			}
+			return l.errorf("comment EOF reached")
+		}
 		if l.peekForward(2) == '-' && l.peekForward(3) == '>' {
 			l.accept("->")
 			break Loop
@@ -390,38 +847,156 @@ Loop:
 	return lexContentStart
 }
 
-func lexNamespace(l *lexer) stateFn {
-	l.acceptRun("<!?")
+// lexProcInst lexes a processing instruction, e.g. <?xml-stylesheet
+// href="feed.xsl"?>, as its target and whatever data follows it
+func lexProcInst(l *lexer) stateFn {
+	l.pos += len("<?")
+	l.ignore()
+	l.acceptRunUntil("?> \t\r\n")
+	if l.pos == l.start {
+		return l.errorf("processing instruction has no target")
+	}
+	l.emit(itemProcInst)
+	l.skipWhitespace()
+
+	if !l.acceptPast("?>") {
+		return l.errorf("processing instruction is never closed")
+	}
+	l.pos -= len("?>")
+	l.emitTrimmed(itemProcInstData)
+	l.pos += len("?>")
 	l.ignore()
+	return lexContentStart
+}
+
+// pseudoAttribute returns the value of the named pseudo-attribute in the
+// data of a processing instruction, e.g. the encoding of <?xml version="1.0"
+// encoding="utf-8"?>
+func pseudoAttribute(data []byte, name string) (value string, ok bool) {
+	l := lexBytes("processing instruction", data)
+	l.state = lexAttributes
+	found := false
+	for lexeme := l.nextItem(); !lexeme.isEnd(); lexeme = l.nextItem() {
+		switch lexeme.typ {
+		case itemAttributeName:
+			found = string(lexeme.val) == name
+		case itemAttributeValue:
+			if found {
+				return string(lexeme.val), true
+			}
+		}
+	}
+	return "", false
+}
+
+// lexDoctype lexes a document type declaration, e.g. <!DOCTYPE rss PUBLIC
+// "-//Netscape Communications//DTD RSS 0.91//EN" "...">, emitting its body
+// and then, separately, any internal subset of entity declarations
+func lexDoctype(l *lexer) stateFn {
+	l.pos += len("<!DOCTYPE")
+	l.skipWhitespace()
+
 	for {
-		l.acceptRunUntil("?!:> ")
-		switch l.peek() {
-		case ':':
-			l.emit(itemNamespace) // namespace, just loop around and keep parsing
-		case ' ':
-			l.emit(itemOpenTag)
-			return lexAttributes
-		case '!', '?':
-			// I'm not sure we will get here
-			l.acceptRun("?!>")
-			l.ignore()
-			l.emit(itemNamespaceEnd)
-			return lexContentStart
+		l.acceptRunUntil("\"'[>")
+		switch quote := l.peek(); quote {
+		case '"', '\'':
+			l.next()
+			if !l.acceptPast(string(quote)) {
+				return l.errorf("DOCTYPE is never closed")
+			}
+			continue
+		case eof:
+			return l.errorf("DOCTYPE is never closed")
+		}
+		break
+	}
+	l.emitTrimmed(itemDoctype)
+
+	if l.accept("[") {
+		l.ignore()
+		if !l.acceptDTD() {
+			return l.errorf("internal DTD subset is never closed")
+		}
+		l.emit(itemDTD)
+		l.accept("]")
+		l.skipWhitespace()
+	}
+	if !l.accept(">") {
+		return l.errorf("DOCTYPE is never closed")
+	}
+	l.ignore()
+	return lexContentStart
+}
+
+// acceptDTD accepts an internal DTD subset, up to its closing bracket.
+// Brackets inside quoted strings and comments don't count.
+func (l *lexer) acceptDTD() bool {
+	for {
+		l.acceptRunUntil("\"'<]")
+		switch r := l.peek(); {
+		case r == ']':
+			return true
+		case r == eof:
+			return false
+		case r == '"' || r == '\'':
+			l.next()
+			if !l.acceptPast(string(r)) {
+				return false
+			}
+		case l.hasPrefix("<!--"):
+			if !l.acceptPast("-->") {
+				return false
+			}
 		default:
-			l.errorf("error parsing tag, unexpected symbol: %v", l.peek())
-			return nil
+			l.next()
 		}
 	}
+}
 
-	return nil
+// lexRawText reads the content of a raw text element, e.g. a script, as
+// text, however much of it looks like markup, up to the element's close tag
+func lexRawText(l *lexer) stateFn {
+	end := l.rawText
+	l.rawText = ""
+	for {
+		l.acceptRunUntil("<")
+		if l.peek() == eof {
+			break
+		}
+		if l.hasPrefix(end) {
+			if r := l.lookAhead(len(end) + 1); r == eof || strings.ContainsRune(" \t\r\n/>", r) {
+				break
+			}
+		}
+		l.accept("<")
+	}
+	if l.pos > l.start {
+		if rawTextElements[end[2:]] {
+			l.emitDecoded(itemText)
+		} else {
+			l.emit(itemText)
+		}
+	}
+	return lexContentStart
 }
 
 func lexTagContents(l *lexer) stateFn {
-	l.skipWhitespace()
-	if l.peek() == '<' {
+	if l.keepSpace {
+		l.ignore()
+	} else {
+		l.skipWhitespace()
+	}
+	if l.peek() == eof || l.peek() == '<' && (!l.lenient || l.atTagStart()) {
 		return lexContentStart
 	}
-	l.acceptRunUntil("<")
-	l.emit(itemText)
+	for {
+		l.acceptRunUntil("<")
+		if !l.lenient || l.peek() == eof || l.atTagStart() {
+			break
+		}
+		l.warnf("stray '<' in text")
+		l.accept("<")
+	}
+	l.emitDecoded(itemText)
 	return lexContentStart
 }
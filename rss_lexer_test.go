@@ -1,8 +1,11 @@
 package rss
 
 import (
+	"io"
 	"log"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func Test_RssLexerHookup(t *testing.T) {
@@ -10,138 +13,304 @@ func Test_RssLexerHookup(t *testing.T) {
 }
 
 func Test_SimpleTag(t *testing.T) {
-	if true {
-		return
-	}
 	input := "<tag>"
-	l := lex("simple tag", input)
-
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "tag", t)
+	for _, l := range testLexers("simple tag", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+	}
 }
 
 func Test_SelfClosingTag(t *testing.T) {
-	if true {
-		return
-	}
 	input := "<tag />"
-	l := lex("self closing tag", input)
+	for _, l := range testLexers("self closing tag", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
 
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "tag", t)
-
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemSelfClosingTag, "", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemSelfClosingTag, "", t)
+	}
 }
 
 func Test_TagWithAttributes(t *testing.T) {
 	input := "<tag attr1=\"val1\" attr2=\"val2\">"
-	l := lex("self closing tag", input)
+	for _, l := range testLexers("self closing tag", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
 
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "tag", t)
-
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeName, "attr1", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeValue, "val1", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeName, "attr2", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeValue, "val2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "attr1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "val1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "attr2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "val2", t)
+	}
 }
 
 func Test_SelfClosingWithAttributes(t *testing.T) {
 	input := "<tag a1=\"v1\" />"
-	l := lex("self closing tag with attributes", input)
+	for _, l := range testLexers("self closing tag with attributes", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
 
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "v1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemSelfClosingTag, "", t)
+	}
+}
 
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeName, "a1", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeValue, "v1", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemSelfClosingTag, "", t)
+func Test_SelfClosingNoSpace(t *testing.T) {
+	input := "<tag/><br />"
+	for _, l := range testLexers("self closing tag without a space", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemSelfClosingTag, "", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "br", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemSelfClosingTag, "", t)
+	}
+}
+
+func Test_SingleQuotedAttributes(t *testing.T) {
+	input := "<link rel='alternate' href='http://example.com/?a=1&amp;b=\"2\"'/>"
+	for _, l := range testLexers("single quoted attributes", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "link", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "rel", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "alternate", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "href", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "http://example.com/?a=1&b=\"2\"", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemSelfClosingTag, "", t)
+	}
+}
+
+func Test_SpacesAroundEquals(t *testing.T) {
+	input := "<tag\n  a1 = \"v1\"\ta2=\t'v2'\r\n>"
+	for _, l := range testLexers("spaces around equals", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "v1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "v2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemEOF, "", t)
+	}
+}
+
+func Test_UnquotedAndBareAttributes(t *testing.T) {
+	input := "<tag a1=v1 selected a2=v2></tag >"
+	for _, l := range testLexers("unquoted attributes", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "v1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "selected", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "v2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "tag", t)
+	}
+}
+
+func Test_AttributeEntities(t *testing.T) {
+	input := "<tag a=\"&lt;&#65;&#x42;&quot;&apos;&gt; &unknown; AT&T &#xZZ;\">"
+	for _, l := range testLexers("attribute entities", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "<AB\"'> &unknown; AT&T &#xZZ;", t)
+	}
+}
+
+func Test_UnclosedAttributeValue(t *testing.T) {
+	input := "<tag a=\"never closed>"
+	for _, l := range testLexers("unclosed attribute value", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemError, "attribute value is never closed", t)
+	}
 }
 
 func Test_ClosingTag(t *testing.T) {
 	input := "<tag><child></child></tag>"
-	l := lex("closing tag", input)
-
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "tag", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "child", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemCloseTag, "child", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemCloseTag, "tag", t)
+	for _, l := range testLexers("closing tag", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "child", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "child", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "tag", t)
+	}
 }
 
 func Test_Text(t *testing.T) {
 	input := "<tag>Child text</tag>"
-	l := lex("text", input)
+	for _, l := range testLexers("text", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "Child text", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "tag", t)
+	}
+}
 
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "tag", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemText, "Child text", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemCloseTag, "tag", t)
+func Test_TextEntities(t *testing.T) {
+	input := "<tag>a &lt; b &amp;&amp; c &#62; d &mdash; AT&T</tag>"
+	for _, l := range testLexers("text entities", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "a < b && c > d — AT&T", t)
+	}
 }
 
 func Test_CData(t *testing.T) {
 	input := "<tag><![CDATA[child & <b>text</b>]]></tag>"
-	l := lex("cdata", input)
+	for _, l := range testLexers("cdata", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemHtml, "child & <b>text</b>", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "tag", t)
+	}
+}
 
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "tag", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemHtml, "child & <b>text</b>", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemCloseTag, "tag", t)
+func Test_XmlDeclaration(t *testing.T) {
+	input := "<?xml version=\"1.0\" encoding=\"UTF-8\" ?>"
+	for _, l := range testLexers("xml declaration", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemProcInst, "xml", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemProcInstData, "version=\"1.0\" encoding=\"UTF-8\"", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemEOF, "", t)
+
+		if encoding, _ := pseudoAttribute([]byte("version=\"1.0\" encoding=\"UTF-8\""), "encoding"); encoding != "UTF-8" {
+			t.Errorf("encoding (%q) not as expected (%q)", encoding, "UTF-8")
+		}
+	}
 }
 
-func Test_NamespaceDeclaration(t *testing.T) {
-	input := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"
-	l := lex("namespace decl", input)
+func Test_StylesheetProcessingInstruction(t *testing.T) {
+	input := "<?xml-stylesheet type=\"text/xsl\" href=\"rss.xsl?v=1\"?><rss></rss>"
+	for _, l := range testLexers("stylesheet", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemProcInst, "xml-stylesheet", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemProcInstData, "type=\"text/xsl\" href=\"rss.xsl?v=1\"", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "rss", t)
+	}
+}
 
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "xml", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeName, "version", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeValue, "1.0", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeName, "encoding", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeValue, "UTF-8", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemNamespaceEnd, "", t)
+// found by FuzzLex: a prefixed processing instruction used to hang
+func Test_PrefixedProcessingInstruction(t *testing.T) {
+	input := "<?xml:stylesheet href=\"a.xsl\"?>"
+	for _, l := range testLexers("prefixed processing instruction", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemProcInst, "xml:stylesheet", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemProcInstData, "href=\"a.xsl\"", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemEOF, "", t)
+	}
+}
+
+func Test_Doctype(t *testing.T) {
+	input := `<!DOCTYPE rss PUBLIC "-//Netscape Communications//DTD RSS 0.91//EN"
+ "http://my.netscape.com/publish/formats/rss-0.91.dtd">
+<rss version="0.91"></rss>`
+	for _, l := range testLexers("doctype", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemDoctype, `rss PUBLIC "-//Netscape Communications//DTD RSS 0.91//EN"
+ "http://my.netscape.com/publish/formats/rss-0.91.dtd"`, t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "rss", t)
+	}
+}
+
+func Test_DoctypeInternalSubset(t *testing.T) {
+	input := `<!doctype rss [
+  <!ENTITY closing "]>">
+  <!-- not the end ] -->
+]><rss/>`
+	for _, l := range testLexers("internal subset", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemDoctype, "rss", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemDTD, `
+  <!ENTITY closing "]>">
+  <!-- not the end ] -->
+`, t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "rss", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemSelfClosingTag, "", t)
+	}
+}
+
+func Test_UnclosedDoctype(t *testing.T) {
+	input := `<!DOCTYPE rss [ <!ENTITY a "b">`
+	for _, l := range testLexers("unclosed doctype", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemDoctype, "rss", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemError, "internal DTD subset is never closed", t)
+	}
 }
 
 func Test_NamespaceTag(t *testing.T) {
 	input := "<rss ns1:a1=\"v1\" ns2:a2=\"v2\"></rss>"
-	l := lex("namespaced tags", input)
-
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "rss", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemNamespace, "ns1", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeName, "a1", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeValue, "v1", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemNamespace, "ns2", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeName, "a2", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemAttributeValue, "v2", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemCloseTag, "rss", t)
+	for _, l := range testLexers("namespaced tags", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "rss", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemNamespace, "ns1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "v1", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemNamespace, "ns2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeName, "a2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemAttributeValue, "v2", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "rss", t)
+	}
 }
 
 func Test_CDataWithBracket(t *testing.T) {
@@ -154,30 +323,220 @@ Eric Lippert, who created one of the first Tolkien Web sites in 1993, sees the a
 ]]>
 </content:encoded>`
 
-	l := lex("namespaced tags", input)
-
-	lexeme := l.nextItem()
-	testLexeme(lexeme, itemNamespace, "content", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "encoded", t)
-	lexeme = l.nextItem()
-	log.Printf("text: %q\n", lexeme)
-	lexeme = l.nextItem()
-	log.Printf("text: %q\n", lexeme)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemNamespace, "content", t)
-	lexeme = l.nextItem()
-	testLexeme(lexeme, itemCloseTag, "encoded", t)
+	for _, l := range testLexers("namespaced tags", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemNamespace, "content", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "encoded", t)
+		lexeme = l.nextItem()
+		log.Printf("text: %q\n", lexeme)
+		lexeme = l.nextItem()
+		log.Printf("text: %q\n", lexeme)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemNamespace, "content", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "encoded", t)
+	}
 }
 
 func Test_Comments(t *testing.T) {
 	input := "<tag><!-- child></child--></tag>"
-	l := lex("comments", input)
+	for _, l := range testLexers("comments", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "tag", t)
+	}
+}
+
+func Test_ReaderMultiByteRunes(t *testing.T) {
+	input := "<title>Café – naïve ☕</title>"
+	for _, l := range testLexers("multi-byte runes", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "title", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "Café – naïve ☕", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "title", t)
+	}
+}
+
+func Test_ReaderLineNumber(t *testing.T) {
+	input := strings.Repeat("<a>\n</a>\n", 2*readChunkSize)
+	l := lexReader("line number", strings.NewReader(input))
+	for lexeme := l.nextItem(); lexeme.typ != itemEOF; lexeme = l.nextItem() {
+	}
+	if l.lineNumber() != 4*readChunkSize+1 {
+		t.Errorf("line number (%d) not as expected (%d)", l.lineNumber(), 4*readChunkSize+1)
+	}
+	if len(l.input) > 2*readChunkSize {
+		t.Errorf("reader window grew to %d bytes", len(l.input))
+	}
+}
+
+func Test_ReaderLexemesOutliveRefill(t *testing.T) {
+	input := strings.Repeat("<title>some text</title>", readChunkSize)
+	l := lexReader("refill", iotest.HalfReader(strings.NewReader(input)))
+	var lexemes []lexeme
+	for lexeme := l.nextItem(); !lexeme.isEnd(); lexeme = l.nextItem() {
+		lexemes = append(lexemes, lexeme)
+	}
+	if len(lexemes) != 3*readChunkSize {
+		t.Fatalf("lexeme count (%d) not as expected (%d)", len(lexemes), 3*readChunkSize)
+	}
+	for i := 0; i < len(lexemes); i += 3 {
+		testLexeme(lexemes[i], itemOpenTag, "title", t)
+		testLexeme(lexemes[i+1], itemText, "some text", t)
+		testLexeme(lexemes[i+2], itemCloseTag, "title", t)
+	}
+}
 
+// emptyReader returns neither data nor an error, however often it's read
+type emptyReader struct{}
+
+func (emptyReader) Read(p []byte) (int, error) {
+	return 0, nil
+}
+
+func Test_ReaderNoProgress(t *testing.T) {
+	l := lexReader("no progress", io.MultiReader(strings.NewReader("<title>"), emptyReader{}))
 	lexeme := l.nextItem()
-	testLexeme(lexeme, itemOpenTag, "tag", t)
+	testLexeme(lexeme, itemOpenTag, "title", t)
 	lexeme = l.nextItem()
-	testLexeme(lexeme, itemCloseTag, "tag", t)
+	testLexeme(lexeme, itemError, "read failed: "+io.ErrNoProgress.Error(), t)
+}
+
+func Test_LexemesDontShareInput(t *testing.T) {
+	input := []byte("<a>text</a>")
+	l := lexBytes("sharing", input)
+	l.nextItem()
+	lexeme := l.nextItem()
+	_ = append(lexeme.val, "XXX"...)
+	if string(input) != "<a>text</a>" {
+		t.Errorf("appending to a lexeme changed the input to %q", input)
+	}
+}
+
+func Test_ErrorEndsLexing(t *testing.T) {
+	input := "<tag>\n<![CDATA[never closed"
+	for _, l := range testLexers("error", input) {
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemError, "CDATA EOF reached", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemEOF, "", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemEOF, "", t)
+
+		err, ok := l.err.(*LexError)
+		if !ok {
+			t.Fatalf("expected a *LexError, received %v", l.err)
+		}
+		if err.Name != "error" || err.Line != 2 || err.Column != 22 {
+			t.Errorf("error position (%s:%d:%d) not as expected (error:2:22)", err.Name, err.Line, err.Column)
+		}
+		if err.Snippet != "never closed" {
+			t.Errorf("error snippet (%q) not as expected (%q)", err.Snippet, "never closed")
+		}
+	}
+}
+
+func Test_LenientStrayLessThan(t *testing.T) {
+	input := "<tag>a < b & c</tag>"
+	for _, l := range testLexers("stray <", input) {
+		l.lenient = true
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "a < b & c", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "tag", t)
+		testWarnings(l, 1, t)
+	}
+}
+
+func Test_LenientResync(t *testing.T) {
+	input := "<a =b><tag>text</tag>"
+	for _, l := range testLexers("resync", input) {
+		l.lenient = true
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "a", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "text", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemEOF, "", t)
+		testWarnings(l, 1, t)
+	}
+}
+
+func Test_LenientUnclosedCData(t *testing.T) {
+	input := "<tag><![CDATA[text</tag><next>more</next>"
+	for _, l := range testLexers("unclosed cdata", input) {
+		l.lenient = true
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "text", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "tag", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "next", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "more", t)
+		testWarnings(l, 1, t)
+	}
+}
+
+func Test_LenientWarningPositions(t *testing.T) {
+	input := "<tag>a < b\nc < d\n  é < f</tag>"
+	for _, l := range testLexers("positions", input) {
+		l.lenient = true
+		for lexeme := l.nextItem(); !lexeme.isEnd(); lexeme = l.nextItem() {
+		}
+		testWarnings(l, 3, t)
+		expected := [][2]int{{1, 8}, {2, 3}, {3, 5}}
+		for i, warning := range l.warnings {
+			if i < len(expected) && (warning.Line != expected[i][0] || warning.Column != expected[i][1]) {
+				t.Errorf("warning position (%d:%d) not as expected (%d:%d)", warning.Line, warning.Column, expected[i][0], expected[i][1])
+			}
+		}
+	}
+}
+
+func Test_LenientWarningLimit(t *testing.T) {
+	input := "<tag>" + strings.Repeat("a < b ", 2*maxWarnings) + "</tag>"
+	for _, l := range testLexers("too many", input) {
+		l.lenient = true
+		for lexeme := l.nextItem(); !lexeme.isEnd(); lexeme = l.nextItem() {
+		}
+		testWarnings(l, maxWarnings+1, t)
+		if last := l.warnings[len(l.warnings)-1]; last.Msg != "too many errors" {
+			t.Errorf("last warning (%s) not as expected (too many errors)", last.Msg)
+		}
+	}
+}
+
+func testWarnings(l *lexer, expected int, t *testing.T) {
+	if l.err != nil {
+		t.Errorf("unexpected error in lenient mode: %v", l.err)
+	}
+	if len(l.warnings) != expected {
+		t.Errorf("number of warnings (%d) not as expected (%d): %v", len(l.warnings), expected, l.warnings)
+	}
+}
+
+// testLexers returns a lexer over the input string, and another reading it
+// one byte at a time, so that every chunk boundary gets exercised
+func testLexers(name, input string) []*lexer {
+	return []*lexer{
+		lex(name, input),
+		lexReader(name, iotest.OneByteReader(strings.NewReader(input))),
+	}
 }
 
 func testLexeme(l lexeme, expectedType lexItemType, expectedVal string, t *testing.T) {
@@ -185,7 +544,38 @@ func testLexeme(l lexeme, expectedType lexItemType, expectedVal string, t *testi
 		t.Errorf("lexeme item type (%q) not as expected (%q)", l.typ, expectedType)
 	}
 
-	if l.val != expectedVal {
+	if string(l.val) != expectedVal {
 		t.Errorf("lexeme val (%q) not as expected (%q)", l.val, expectedVal)
 	}
 }
+
+func Test_KeepSpace(t *testing.T) {
+	input := "<p> <b>a</b> <i>b</i>\n</p>"
+	for _, l := range testLexers("keep space", input) {
+		l.keepSpace = true
+		lexeme := l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "p", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, " ", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "b", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "a", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "b", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, " ", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemOpenTag, "i", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "b", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "i", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemText, "\n", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemCloseTag, "p", t)
+		lexeme = l.nextItem()
+		testLexeme(lexeme, itemEOF, "", t)
+	}
+}
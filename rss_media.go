@@ -0,0 +1,305 @@
+package rss
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media holds an entry's Media RSS (media:) elements. Those that aren't in
+// a <media:group> are on Media itself.
+type Media struct {
+	MediaGroup
+	Groups []MediaGroup
+}
+
+// MediaGroup is a <media:group>, the contents of which are versions of the
+// same thing, e.g. a video in several sizes
+type MediaGroup struct {
+	MediaDetails
+	Contents []MediaContent
+}
+
+// MediaContent is a <media:content>, a media file
+type MediaContent struct {
+	MediaDetails
+	URL       string
+	Type      string
+	Medium    string // image, audio, video, document or executable
+	FileSize  int64  // in bytes, zero if not given
+	Width     int
+	Height    int
+	Duration  time.Duration
+	IsDefault bool // the one to use out of a group
+}
+
+// MediaDetails are the elements that may describe an entry's media, a
+// group or a single media file
+type MediaDetails struct {
+	Thumbnails  []MediaThumbnail
+	Description string // as html
+	Credits     []MediaCredit
+}
+
+// MediaThumbnail is a <media:thumbnail>. Its width and height are zero
+// where they're not given.
+type MediaThumbnail struct {
+	URL    string
+	Width  int
+	Height int
+}
+
+// MediaCredit is someone credited with the media, e.g. its producer
+type MediaCredit struct {
+	Name   string
+	Role   string
+	Scheme string // where the role comes from, urn:ebu by default
+}
+
+// mediaOf returns the entry's media elements, adding them if need be
+func mediaOf(entry *Entry) *Media {
+	if entry.Media == nil {
+		entry.Media = new(Media)
+	}
+	return entry.Media
+}
+
+// Entry handlers
+
+// handleEntryThumbnail handles <media:thumbnail url="..." width="..."
+// height="..."/>
+func handleEntryThumbnail(e *Element, entry *Entry) {
+	if thumbnail, ok := extractMediaThumbnail(e); ok {
+		media := mediaOf(entry)
+		media.Thumbnails = append(media.Thumbnails, thumbnail)
+	}
+}
+
+func handleEntryMediaContent(e *Element, entry *Entry) {
+	if content, ok := extractMediaContent(e); ok {
+		media := mediaOf(entry)
+		media.Contents = append(media.Contents, content)
+	}
+}
+
+func handleEntryMediaGroup(e *Element, entry *Entry) {
+	group := extractMediaGroup(e)
+	if len(group.Contents) > 0 || len(group.Thumbnails) > 0 {
+		media := mediaOf(entry)
+		media.Groups = append(media.Groups, group)
+	}
+}
+
+func handleEntryMediaDescription(e *Element, entry *Entry) {
+	if description, ok := extractMediaDescription(e); ok {
+		mediaOf(entry).Description = description
+	}
+}
+
+func handleEntryMediaCredit(e *Element, entry *Entry) {
+	if credit, ok := extractMediaCredit(e); ok {
+		media := mediaOf(entry)
+		media.Credits = append(media.Credits, credit)
+	}
+}
+
+// extractMediaDetail reads e into details if it's one of the elements
+// they hold, and otherwise leaves it to be skipped
+func extractMediaDetail(e *Element, details *MediaDetails) {
+	if e.Namespace != nsMedia {
+		return
+	}
+	switch e.Name {
+	case "thumbnail":
+		if thumbnail, ok := extractMediaThumbnail(e); ok {
+			details.Thumbnails = append(details.Thumbnails, thumbnail)
+		}
+	case "description":
+		if description, ok := extractMediaDescription(e); ok {
+			details.Description = description
+		}
+	case "credit":
+		if credit, ok := extractMediaCredit(e); ok {
+			details.Credits = append(details.Credits, credit)
+		}
+	}
+}
+
+// extractMediaGroup reads a <media:group> and the contents in it
+func extractMediaGroup(e *Element) (group MediaGroup) {
+	e.Children(func(child *Element) {
+		if child.Namespace != nsMedia || child.Name != "content" {
+			extractMediaDetail(child, &group.MediaDetails)
+			return
+		}
+		if content, ok := extractMediaContent(child); ok {
+			group.Contents = append(group.Contents, content)
+		}
+	})
+	return group
+}
+
+// extractMediaContent reads <media:content url="..." type="..."
+// medium="..." fileSize="..." width="..." height="..." duration="..."
+// isDefault="..."/>, and any thumbnails, description and credits in it
+func extractMediaContent(e *Element) (content MediaContent, ok bool) {
+	content.URL = e.ResolveURL(e.Attr("url"))
+	content.Type = e.Attr("type")
+	content.Medium = e.Attr("medium")
+	content.FileSize, _ = strconv.ParseInt(strings.TrimSpace(e.Attr("fileSize")), 10, 64)
+	content.Width = atoi(e.Attr("width"))
+	content.Height = atoi(e.Attr("height"))
+	if seconds, err := strconv.ParseFloat(strings.TrimSpace(e.Attr("duration")), 64); err == nil {
+		content.Duration, _ = secondsDuration(seconds)
+	}
+	content.IsDefault = e.Attr("isDefault") == "true"
+
+	e.Children(func(child *Element) {
+		extractMediaDetail(child, &content.MediaDetails)
+	})
+	return content, content.URL != ""
+}
+
+func extractMediaThumbnail(e *Element) (MediaThumbnail, bool) {
+	thumbnail := MediaThumbnail{
+		URL:    e.ResolveURL(e.Attr("url")),
+		Width:  atoi(e.Attr("width")),
+		Height: atoi(e.Attr("height")),
+	}
+	return thumbnail, thumbnail.URL != ""
+}
+
+// extractMediaDescription reads a <media:description>, which is plain text
+// unless its type is html
+func extractMediaDescription(e *Element) (string, bool) {
+	text := e.Text()
+	if text == "" {
+		return "", false
+	}
+	if e.Attr("type") == "html" {
+		return e.ResolveHtml(text), true
+	}
+	return html.EscapeString(text), true
+}
+
+func extractMediaCredit(e *Element) (MediaCredit, bool) {
+	text := e.Text()
+	if text == "" {
+		return MediaCredit{}, false
+	}
+	return MediaCredit{
+		Name:   strings.TrimSpace(text),
+		Role:   e.Attr("role"),
+		Scheme: e.Attr("scheme"),
+	}, true
+}
+
+// atoi is a width, height or the like, or zero if it isn't a number
+func atoi(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Thumbnails
+
+// bestThumbnail picks the entry's thumbnail, from the first of:
+//   - the largest of its media:thumbnails, including those of its groups
+//     and contents, or the first if none give a size
+//   - the largest of its media:contents that are images
+//   - the first of its enclosures that's an image
+//   - its itunes:image
+//   - the first <img> in its content, encoded content or summary that
+//     isn't a tracking pixel
+func bestThumbnail(entry *Entry) string {
+	if media := entry.Media; media != nil {
+		var thumbnails []MediaThumbnail
+		var images []MediaContent
+		for _, group := range append([]MediaGroup{media.MediaGroup}, media.Groups...) {
+			thumbnails = append(thumbnails, group.Thumbnails...)
+			for _, content := range group.Contents {
+				thumbnails = append(thumbnails, content.Thumbnails...)
+				if content.Medium == "image" || strings.HasPrefix(content.Type, "image/") {
+					images = append(images, content)
+				}
+			}
+		}
+
+		best := -1
+		for i, thumbnail := range thumbnails {
+			if best < 0 || thumbnail.Width*thumbnail.Height > thumbnails[best].Width*thumbnails[best].Height {
+				best = i
+			}
+		}
+		if best >= 0 {
+			return thumbnails[best].URL
+		}
+
+		for i, image := range images {
+			if best < 0 || image.Width*image.Height > images[best].Width*images[best].Height {
+				best = i
+			}
+		}
+		if best >= 0 {
+			return images[best].URL
+		}
+	}
+
+	for _, enclosure := range entry.Enclosures {
+		if strings.HasPrefix(enclosure.Type, "image/") {
+			return enclosure.URL
+		}
+	}
+	if entry.Podcast != nil && entry.Podcast.Image != "" {
+		return entry.Podcast.Image
+	}
+	for _, content := range []string{entry.Content, entry.Encoded, entry.Summary} {
+		if image := firstImage(content); image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// firstImage finds the src of the first <img> in content, passing over
+// images no bigger than a pixel, which are there to track readers
+func firstImage(content string) string {
+	if !strings.Contains(content, "<img") && !strings.Contains(content, "<IMG") {
+		return ""
+	}
+
+	l := lex("content", content)
+	l.lenient = true
+	inImg, tracking := false, false
+	var attr, src string
+	for lexeme := l.nextItem(); ; lexeme = l.nextItem() {
+		switch lexeme.typ {
+		case itemAttributeName:
+			attr = strings.ToLower(string(lexeme.val))
+			continue
+		case itemAttributeValue:
+			switch value := strings.TrimSpace(string(lexeme.val)); attr {
+			case "src":
+				src = value
+			case "width", "height":
+				if isTrackingPixelSize(value) {
+					tracking = true
+				}
+			}
+			continue
+		}
+
+		// anything else is the end of the tag
+		if inImg && src != "" && !tracking {
+			return src
+		}
+		if lexeme.isEnd() {
+			return ""
+		}
+		inImg = lexeme.typ == itemOpenTag && strings.EqualFold(string(lexeme.val), "img")
+		src, tracking = "", false
+	}
+}
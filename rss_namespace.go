@@ -0,0 +1,309 @@
+package rss
+
+import "bytes"
+
+// Namespaces the parser knows about
+const (
+	nsAtom    = "http://www.w3.org/2005/Atom"
+	nsAtom03  = "http://purl.org/atom/ns#"
+	nsContent = "http://purl.org/rss/1.0/modules/content/"
+	nsDC      = "http://purl.org/dc/elements/1.1/"
+	nsItunes  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	nsMedia   = "http://search.yahoo.com/mrss/"
+	nsPodcast = "https://podcastindex.org/namespace/1.0"
+	nsRDF     = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsRSS090  = "http://my.netscape.com/rdf/simple/0.9/"
+	nsRSS10   = "http://purl.org/rss/1.0/"
+	nsSlash   = "http://purl.org/rss/1.0/modules/slash/"
+	nsWfw     = "http://wellformedweb.org/CommentAPI/"
+	nsXHTML   = "http://www.w3.org/1999/xhtml"
+	nsXML     = "http://www.w3.org/XML/1998/namespace"
+	nsXMLNS   = "http://www.w3.org/2000/xmlns/"
+)
+
+// the namespaces usually bound to each prefix, for feeds that use a
+// prefix without declaring it
+var conventionalPrefixes = map[string]string{
+	"atom":    nsAtom,
+	"content": nsContent,
+	"dc":      nsDC,
+	"itunes":  nsItunes,
+	"media":   nsMedia,
+	"podcast": nsPodcast,
+	"rdf":     nsRDF,
+	"slash":   nsSlash,
+	"wfw":     nsWfw,
+}
+
+// namespaces that some RSS 0.9x and 2.0 feeds put their elements in,
+// which we treat the same as no namespace at all
+var rssNamespaces = map[string]bool{
+	"http://backend.userland.com/rss2":          true,
+	"http://blogs.law.harvard.edu/tech/rss":     true,
+	"http://backend.userland.com/rss092":        true,
+	"http://purl.org/net/rss1.1#compatibility/": true,
+	nsRSS090: true,
+	nsRSS10:  true,
+}
+
+// namespaces read as another: Atom 0.3 as Atom 1.0, whose elements mostly
+// have the same names, and the other URIs podcast feeds give their
+// namespaces
+var namespaceAliases = map[string]string{
+	nsAtom03: nsAtom,
+	"http://www.itunes.com/DTDs/Podcast-1.0.dtd":                                    nsItunes,
+	"https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/1.0.md":   nsPodcast,
+	"https://github.com/Podcastindex-org/podcast-namespace/blob/master/docs/1.0.md": nsPodcast,
+}
+
+// xmlName is an element name resolved to its namespace
+type xmlName struct {
+	space string // the namespace URI
+	local string
+}
+
+// name is the resolved name of a tag or attribute lexeme
+func (l lexeme) name() xmlName {
+	return xmlName{l.ns, string(l.val)}
+}
+
+// nsScope is an open element, and the prefixes in scope in it. The scopes
+// of an nsReader are the path to the current element.
+type nsScope struct {
+	prefix      string // the element's name, as written
+	local       []byte
+	space       string            // the namespace the element is in, once resolved
+	base        string            // the element's xml:base, resolved, or its parent's
+	prefixes    map[string]string // prefix to namespace URI, "" being the default
+	ownPrefixes bool              // prefixes is the scope's own, not its parent's
+}
+
+// nsReader sits between the lexer and the parser and resolves names to
+// namespaces. Namespace lexemes are folded into the name that follows
+// them, and every tag and attribute name carries its prefix and namespace
+// URI. It reads ahead through each start tag's attributes, as an element
+// may declare its own namespace.
+type nsReader struct {
+	*lexer
+	scopes   []nsScope
+	peeked   lexemeQueue       // lexemes read ahead from the lexer
+	resolved lexemeQueue       // lexemes ready to be handed out
+	attrs    []lexeme          // scratch space for startElement
+	interned map[string]string // prefixes seen so far, so each is allocated once
+	docBase  string            // the feed's URL, if its name is one
+}
+
+func newNsReader(l *lexer) *nsReader {
+	return &nsReader{lexer: l, docBase: documentBase(l.name)}
+}
+
+// nextItem returns the next item, with names resolved
+func (r *nsReader) nextItem() lexeme {
+	if lexeme, ok := r.resolved.pop(); ok {
+		return lexeme
+	}
+
+	lexeme := r.nextName()
+	switch lexeme.typ {
+	case itemOpenTag:
+		return r.startElement(lexeme)
+	case itemCloseTag:
+		lexeme.ns = r.resolve(lexeme.prefix, true)
+		r.endElement(lexeme)
+	case itemSelfClosingTag:
+		r.endElement(lexeme)
+	}
+	return lexeme
+}
+
+// nextRaw returns the next lexeme from the lexer, after any we've peeked at
+func (r *nsReader) nextRaw() lexeme {
+	if lexeme, ok := r.peeked.pop(); ok {
+		return lexeme
+	}
+	return r.lexer.nextItem()
+}
+
+// nextName returns the next lexeme with any namespace prefix folded in.
+// Lexemes that were put back have theirs already.
+func (r *nsReader) nextName() lexeme {
+	lexeme := r.nextRaw()
+	if lexeme.typ != itemNamespace {
+		return lexeme
+	}
+	prefix := lexeme.val
+	for lexeme = r.nextRaw(); lexeme.typ == itemNamespace; lexeme = r.nextRaw() {
+		prefix = append(append(prefix, ':'), lexeme.val...)
+	}
+	lexeme.prefix = r.intern(prefix)
+	return lexeme
+}
+
+// intern returns prefix as a string, allocating only the first time
+// it's seen
+func (r *nsReader) intern(prefix []byte) string {
+	if s, ok := r.interned[string(prefix)]; ok {
+		return s
+	}
+	if r.interned == nil {
+		r.interned = make(map[string]string)
+	}
+	s := string(prefix)
+	r.interned[s] = s
+	return s
+}
+
+// startElement reads the attributes of the start tag, declaring any
+// namespaces they bind before resolving the names
+func (r *nsReader) startElement(tag lexeme) lexeme {
+	scope := nsScope{prefix: tag.prefix, local: tag.val, base: r.base()}
+	if len(r.scopes) > 0 {
+		// the parent's prefixes are shared until the element declares one
+		scope.prefixes = r.scopes[len(r.scopes)-1].prefixes
+	}
+
+	attrs := r.attrs[:0]
+	for {
+		name := r.nextName()
+		if name.typ != itemAttributeName {
+			// that's the end of the start tag; put it back, prefix and all
+			r.peeked.push(name)
+			break
+		}
+		value := r.nextRaw()
+		if value.typ != itemAttributeValue {
+			r.peeked.push(value)
+			attrs = append(attrs, name)
+			break
+		}
+
+		switch {
+		case name.prefix == "xmlns":
+			scope.declare(string(name.val), string(value.val))
+		case name.prefix == "" && string(name.val) == "xmlns":
+			scope.declare("", string(value.val))
+		case name.prefix == "xml" && string(name.val) == "base":
+			scope.base = resolveURL(scope.base, string(value.val))
+		}
+		attrs = append(attrs, name, value)
+	}
+	r.scopes = append(r.scopes, scope)
+
+	tag.ns = r.resolve(tag.prefix, true)
+	r.scopes[len(r.scopes)-1].space = tag.ns
+	for _, attr := range attrs {
+		if attr.typ == itemAttributeName {
+			attr.ns = r.resolve(attr.prefix, false)
+		}
+		r.resolved.push(attr)
+	}
+	r.attrs = attrs
+	return tag
+}
+
+// maxUnclosed is how many elements left open a close tag may close along
+// with the one it names, so that stray close tags in deeply nested input
+// don't each search every element open
+const maxUnclosed = 64
+
+// endElement closes the scope of the element a close tag names, and any
+// left open inside it (e.g. an unclosed <br>). Any other lexeme closes
+// the innermost scope.
+func (r *nsReader) endElement(tag lexeme) {
+	for i := len(r.scopes) - 1; i >= 0 && i >= len(r.scopes)-1-maxUnclosed; i-- {
+		scope := &r.scopes[i]
+		if tag.typ != itemCloseTag || scope.prefix == tag.prefix && bytes.Equal(scope.local, tag.val) {
+			r.scopes = r.scopes[:i]
+			return
+		}
+	}
+}
+
+// parent is the name of the element enclosing the one just opened, or
+// the empty name at the root
+func (r *nsReader) parent() xmlName {
+	if len(r.scopes) < 2 {
+		return xmlName{}
+	}
+	scope := &r.scopes[len(r.scopes)-2]
+	return xmlName{scope.space, string(scope.local)}
+}
+
+// base is the URL that relative URLs in the element just opened are
+// relative to: the innermost xml:base, or failing that, the feed's URL
+func (r *nsReader) base() string {
+	if len(r.scopes) == 0 {
+		return r.docBase
+	}
+	return r.scopes[len(r.scopes)-1].base
+}
+
+// assumeDefault puts the element just opened, and everything in it
+// without a namespace of its own, in uri, as if it had declared uri as
+// the default namespace
+func (r *nsReader) assumeDefault(uri string) {
+	scope := &r.scopes[len(r.scopes)-1]
+	scope.declare("", uri)
+	scope.space = uri
+}
+
+// declare binds prefix to uri, copying the prefixes inherited from the
+// parent first, so that looking a prefix up is one map lookup however deep
+// the element is
+func (s *nsScope) declare(prefix, uri string) {
+	if !s.ownPrefixes {
+		prefixes := make(map[string]string, len(s.prefixes)+1)
+		for p, u := range s.prefixes {
+			prefixes[p] = u
+		}
+		s.prefixes, s.ownPrefixes = prefixes, true
+	}
+	s.prefixes[prefix] = uri
+}
+
+// resolve finds the namespace bound to prefix. Unprefixed attributes
+// aren't in any namespace, whereas unprefixed elements are in the default.
+func (r *nsReader) resolve(prefix string, isElement bool) string {
+	return canonicalNamespace(r.declared(prefix, isElement))
+}
+
+// canonicalNamespace is the namespace uri is read as: none for RSS's own
+// namespaces, and for aliases, the namespace they stand for
+func canonicalNamespace(uri string) string {
+	if rssNamespaces[uri] {
+		return ""
+	}
+	if alias, ok := namespaceAliases[uri]; ok {
+		return alias
+	}
+	return uri
+}
+
+// declared is resolve without reading RSS namespaces as none, or aliased
+// namespaces as the one they stand for, e.g. to tell which version of a
+// format a feed is in
+func (r *nsReader) declared(prefix string, isElement bool) string {
+	switch {
+	case prefix == "xml":
+		return nsXML
+	case prefix == "xmlns":
+		return nsXMLNS
+	case prefix == "" && !isElement:
+		return ""
+	}
+
+	if len(r.scopes) > 0 {
+		if uri, ok := r.scopes[len(r.scopes)-1].prefixes[prefix]; ok {
+			return uri
+		}
+	}
+	return conventionalPrefixes[prefix]
+}
+
+// qualifiedName is a tag name as written, including any prefix
+func qualifiedName(l lexeme) string {
+	if l.prefix == "" {
+		return string(l.val)
+	}
+	return l.prefix + ":" + string(l.val)
+}
@@ -1,88 +1,434 @@
 package rss
 
 import (
+	"bytes"
 	"fmt"
 	"html"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type RssParser struct {
-	lexer         *lexer
+	reader        *nsReader
 	feed          *Feed
 	entries       []*Entry
-	feedHandlers  map[string]feedHandler
-	entryHandlers map[string]entryHandler
+	feedHandlers  map[xmlName]feedExtension
+	entryHandlers map[xmlName]entryExtension
+	ownHandlers   bool      // the handler maps are the parser's own copies
+	element       Element   // the element being handled, reused for each
+	fetched       time.Time // when the feed was fetched, see SetFetchTime
+}
+
+// the built-in handlers, for the elements of RSS, Atom and the extensions
+// we know, are registered the same way as anyone else's
+func init() {
+	RegisterFeedExtension("", "title", handleFeedTitle)
+	RegisterFeedExtension("", "link", handleFeedLink)
+	RegisterFeedExtension("", "description", handleFeedSubtitle)
+	RegisterFeedExtension("", "subtitle", handleFeedSubtitle)
+	RegisterFeedExtension("", "copyright", handleFeedCopyright)
+	RegisterFeedExtension("", "author", handleFeedAuthor)
+	RegisterFeedExtension("", "managingEditor", handleFeedAuthor)
+	RegisterFeedExtension("", "pubDate", handleFeedPubDate)
+	RegisterFeedExtension("", "category", handleFeedCategory)
+	RegisterFeedExtension("", "generator", handleFeedGenerator)
+	RegisterFeedExtension("", "logo", handleFeedLogo)
+	RegisterFeedExtension("", "icon", handleFeedIcon)
+	RegisterFeedExtension("", "image", handleFeedImage)
+	RegisterFeedExtension("", "language", handleFeedLanguage)
+	RegisterFeedExtension(nsDC, "creator", handleFeedDcCreator)
+	RegisterFeedExtension(nsDC, "date", handleFeedDcDate)
+	RegisterFeedExtension(nsDC, "subject", handleFeedDcSubject)
+	RegisterFeedExtension(nsDC, "rights", handleFeedDcRights)
+	RegisterFeedExtension(nsDC, "language", handleFeedDcLanguage)
+	RegisterFeedExtension(nsDC, "publisher", handleFeedDcPublisher)
+	RegisterFeedExtension(nsItunes, "image", handleFeedItunesImage)
+	RegisterFeedExtension(nsItunes, "explicit", handleFeedItunesExplicit)
+	RegisterFeedExtension(nsItunes, "category", handleFeedItunesCategory)
+	RegisterFeedExtension(nsPodcast, "guid", handleFeedPodcastGuid)
+	RegisterFeedExtension(nsPodcast, "person", handleFeedPodcastPerson)
+	RegisterFeedExtension(nsAtom, "title", handleFeedTitle)
+	RegisterFeedExtension(nsAtom, "link", handleFeedLink)
+	RegisterFeedExtension(nsAtom, "subtitle", handleFeedSubtitle)
+	RegisterFeedExtension(nsAtom, "rights", handleFeedCopyright)
+	RegisterFeedExtension(nsAtom, "author", handleFeedAuthor)
+	RegisterFeedExtension(nsAtom, "contributor", handleFeedContributor)
+	RegisterFeedExtension(nsAtom, "updated", handleFeedPubDate)
+	RegisterFeedExtension(nsAtom, "category", handleFeedCategory)
+	RegisterFeedExtension(nsAtom, "generator", handleFeedGenerator)
+	RegisterFeedExtension(nsAtom, "logo", handleFeedLogo)
+	RegisterFeedExtension(nsAtom, "icon", handleFeedIcon)
+	RegisterFeedExtension(nsAtom, "tagline", handleFeedSubtitle)    // Atom 0.3
+	RegisterFeedExtension(nsAtom, "copyright", handleFeedCopyright) // Atom 0.3
+	RegisterFeedExtension(nsAtom, "modified", handleFeedPubDate)    // Atom 0.3
+
+	RegisterEntryExtension("", "title", handleEntryTitle)
+	RegisterEntryExtension("", "link", handleEntryLink)
+	RegisterEntryExtension("", "subtitle", handleEntrySubtitle)
+	RegisterEntryExtension("", "author", handleEntryAuthor)
+	RegisterEntryExtension("", "id", handleEntryGuid)
+	RegisterEntryExtension("", "guid", handleEntryGuid)
+	RegisterEntryExtension("", "pubDate", handleEntryPublishDate)
+	RegisterEntryExtension("", "updatedDate", handleEntryUpdatedDate)
+	RegisterEntryExtension("", "summary", handleEntrySummary)
+	RegisterEntryExtension("", "description", handleEntrySummary)
+	RegisterEntryExtension("", "content", handleEntryContent)
+	RegisterEntryExtension("", "source", handleEntrySource)
+	RegisterEntryExtension("", "comments", handleEntryComments)
+	RegisterEntryExtension("", "enclosure", handleEntryEnclosure)
+	RegisterEntryExtension("", "category", handleEntryCategory)
+	RegisterEntryExtension(nsContent, "encoded", handleEntryEncoded)
+	RegisterEntryExtension(nsDC, "creator", handleEntryDcCreator)
+	RegisterEntryExtension(nsDC, "date", handleEntryDcDate)
+	RegisterEntryExtension(nsDC, "subject", handleEntryDcSubject)
+	RegisterEntryExtension(nsDC, "rights", handleEntryDcRights)
+	RegisterEntryExtension(nsDC, "language", handleEntryDcLanguage)
+	RegisterEntryExtension(nsDC, "publisher", handleEntryDcPublisher)
+	RegisterEntryExtension(nsItunes, "duration", handleEntryItunesDuration)
+	RegisterEntryExtension(nsItunes, "episode", handleEntryItunesEpisode)
+	RegisterEntryExtension(nsItunes, "season", handleEntryItunesSeason)
+	RegisterEntryExtension(nsItunes, "explicit", handleEntryItunesExplicit)
+	RegisterEntryExtension(nsItunes, "image", handleEntryItunesImage)
+	RegisterEntryExtension(nsPodcast, "transcript", handleEntryPodcastTranscript)
+	RegisterEntryExtension(nsPodcast, "chapters", handleEntryPodcastChapters)
+	RegisterEntryExtension(nsPodcast, "person", handleEntryPodcastPerson)
+	RegisterEntryExtension(nsMedia, "thumbnail", handleEntryThumbnail)
+	RegisterEntryExtension(nsMedia, "content", handleEntryMediaContent)
+	RegisterEntryExtension(nsMedia, "group", handleEntryMediaGroup)
+	RegisterEntryExtension(nsMedia, "description", handleEntryMediaDescription)
+	RegisterEntryExtension(nsMedia, "credit", handleEntryMediaCredit)
+	RegisterEntryExtension(nsAtom, "title", handleEntryTitle)
+	RegisterEntryExtension(nsAtom, "link", handleEntryLink)
+	RegisterEntryExtension(nsAtom, "subtitle", handleEntrySubtitle)
+	RegisterEntryExtension(nsAtom, "author", handleEntryAuthor)
+	RegisterEntryExtension(nsAtom, "contributor", handleEntryContributor)
+	RegisterEntryExtension(nsAtom, "category", handleEntryCategory)
+	RegisterEntryExtension(nsAtom, "id", handleEntryGuid)
+	RegisterEntryExtension(nsAtom, "published", handleEntryPublishDate)
+	RegisterEntryExtension(nsAtom, "updated", handleEntryUpdatedDate)
+	RegisterEntryExtension(nsAtom, "summary", handleEntryAtomSummary)
+	RegisterEntryExtension(nsAtom, "content", handleEntryAtomContent)
+	RegisterEntryExtension(nsAtom, "source", handleEntrySource)
+	RegisterEntryExtension(nsAtom, "rights", handleEntryCopyright)
+	RegisterEntryExtension(nsAtom, "issued", handleEntryPublishDate)   // Atom 0.3
+	RegisterEntryExtension(nsAtom, "modified", handleEntryUpdatedDate) // Atom 0.3
+}
+
+// ParseFeed parses a feed in any of the formats we know: RSS 0.9x, 1.0 and
+// 2.0, Atom 0.3 and 1.0, and JSON Feed. contentType, an HTTP Content-Type
+// header, may be empty; it's used to tell JSON from XML and for the
+// charset, but the data itself is sniffed when it doesn't say. The format
+// and version found are recorded on the feed.
+func ParseFeed(name string, data []byte, contentType string) (feed *Feed, entries []*Entry, err error) {
+	if isJsonFeed(data, contentType) {
+		return NewJsonFeedParser(name, bytes.NewReader(data)).Parse()
+	}
+
+	data, charsetErr := utf8Bytes(data, contentType)
+	feed, entries, err = newParser(lexBytes(name, data), charsetErr).Parse()
+	if err == nil && feed.Format == "" {
+		err = fmt.Errorf("%s: no RSS, Atom or JSON feed found", name)
+	}
+	return feed, entries, err
+}
+
+// NewParser creates a parser over a feed that is already in memory. Feeds
+// in other charsets are transcoded to UTF-8 as directed by their byte
+// order mark or XML declaration.
+func NewParser(name, input string) *RssParser {
+	return NewParserFromBytes(name, []byte(input))
 }
 
-func NewParser(name, input string) *RssParser {
+// NewParserFromBytes is NewParser for a feed held as bytes. The lexer
+// works on data in place, rather than copying it, so data must not be
+// modified until Parse has returned.
+func NewParserFromBytes(name string, data []byte) *RssParser {
+	data, err := utf8Bytes(data, "")
+	return newParser(lexBytes(name, data), err)
+}
+
+// NewParserFromReader creates a parser that reads the feed from r as it
+// goes, so memory use doesn't grow with the size of the feed
+func NewParserFromReader(name string, r io.Reader) *RssParser {
+	return NewParserWithContentType(name, r, "")
+}
+
+// NewParserWithContentType creates a parser that reads the feed from r,
+// taking the charset from contentType (an HTTP Content-Type header) unless
+// the feed starts with a byte order mark.
+func NewParserWithContentType(name string, r io.Reader, contentType string) *RssParser {
+	r, err := utf8Reader(r, contentType)
+	return newParser(lexReader(name, r), err)
+}
+
+// newParser creates a parser reading from l. A non-nil charsetErr means the
+// input couldn't be transcoded, which is only worth a warning: most
+// unknown charsets are ASCII-compatible enough to parse anyway.
+func newParser(l *lexer, charsetErr error) *RssParser {
+	if charsetErr != nil {
+		l.warnf("%v", charsetErr)
+	}
 	return &RssParser{
-		lexer:   lex(name, input),
-		entries: make([]*Entry, 0, 20),
-		feedHandlers: map[string]feedHandler{
-			"title":          handleFeedTitle,
-			"link":           handleFeedLink,
-			"description":    handleFeedSubtitle,
-			"subtitle":       handleFeedSubtitle,
-			"copyright":      handleFeedCopyright,
-			"author":         handleFeedAuthor,
-			"managingEditor": handleFeedAuthor,
-			"pubDate":        handleFeedPubDate,
-			"category":       handleFeedCategory,
-			"generator":      handleFeedGenerator,
-			"logo":           handleFeedLogo,
-			"icon":           handleFeedIcon,
-		},
-		entryHandlers: map[string]entryHandler{
-			"title":       handleEntryTitle,
-			"link":        handleEntryLink,
-			"subtitle":    handleEntrySubtitle,
-			"id":          handleEntryGuid,
-			"guid":        handleEntryGuid,
-			"pubDate":     handleEntryUpdatedDate,
-			"updatedDate": handleEntryUpdatedDate,
-			"summary":     handleEntrySummary,
-			"description": handleEntrySummary,
-			"encoded":     handleEntryEncoded,
-			"content":     handleEntryContent,
-			"source":      handleEntrySource,
-			"comments":    handleEntryComments,
-			"enclosure":   handleEntryEnclosure,
-		},
+		reader:        newNsReader(l),
+		entries:       make([]*Entry, 0, 20),
+		feedHandlers:  feedHandlers,
+		entryHandlers: entryHandlers,
 	}
 }
 
+// SetLenient sets whether the parser should recover from malformed input.
+// When lenient, Parse returns whatever it could salvage, along with a
+// LexErrorList describing each problem it worked around.
+func (r *RssParser) SetLenient(lenient bool) {
+	r.reader.lenient = lenient
+}
+
+// SetFetchTime sets when the feed was fetched, which is when its entries
+// were first seen: entries without plausible dates of their own are dated
+// by it. It's when Parse is called by default.
+func (r *RssParser) SetFetchTime(fetched time.Time) {
+	r.fetched = fetched
+}
+
 func (r *RssParser) Parse() (feed *Feed, entries []*Entry, err error) {
+	if r.fetched.IsZero() {
+		r.fetched = time.Now()
+	}
+
 	// skip everything before the feed as unnecessary
+	r.feed = new(Feed)
 	r.skipUntilFeedTag()
 	r.populateFeed()
-	r.populateEntries()
+	if r.feed.DublinCore != nil {
+		r.feed.DublinCore.fillFeed(r.feed)
+	}
 
+	if r.reader.err != nil {
+		return r.feed, r.entries, r.reader.err
+	}
+	if r.reader.lenient && len(r.reader.warnings) > 0 {
+		return r.feed, r.entries, r.reader.warnings
+	}
 	return r.feed, r.entries, nil
 }
 
-type feedHandler func(l *lexer, feed *Feed)
-type entryHandler func(l *lexer, entry *Entry)
-
-func skipUntilTagClose(l *lexer) {
-	for lexeme := l.nextItem(); lexeme.typ != itemCloseTag && lexeme.typ != itemSelfClosingTag; lexeme = l.nextItem() {
+func skipUntilTagClose(l *nsReader) {
+	for lexeme := l.nextItem(); lexeme.typ != itemCloseTag && lexeme.typ != itemSelfClosingTag && !lexeme.isEnd(); lexeme = l.nextItem() {
 	}
 }
 
-func extractTextAndSkip(l *lexer) *lexeme {
-	var lexeme lexeme
-	for lexeme = l.nextItem(); lexeme.typ != itemText && lexeme.typ != itemHtml && lexeme.typ != itemCloseTag && lexeme.typ != itemSelfClosingTag; lexeme = l.nextItem() {
+// extractTextFrom reads the text of the current element, starting from
+// lexeme, and skips the rest of it
+func extractTextFrom(l *nsReader, lexeme lexeme) (text lexeme, ok bool) {
+	for ; lexeme.typ != itemText && lexeme.typ != itemHtml && lexeme.typ != itemCloseTag && lexeme.typ != itemSelfClosingTag && !lexeme.isEnd(); lexeme = l.nextItem() {
 	}
 	if lexeme.typ == itemText || lexeme.typ == itemHtml {
+		if l.lenient {
+			return foldMarkup(l, lexeme), true
+		}
 		skipUntilTagClose(l)
-		return &lexeme
+		return lexeme, true
+	}
+	return lexeme, false
+}
+
+// skipElement skips to the end of the current element, children and all,
+// starting from lexeme
+func skipElement(l *nsReader, lexeme lexeme) {
+	depth := 0
+	for ; !lexeme.isEnd(); lexeme = l.nextItem() {
+		switch lexeme.typ {
+		case itemOpenTag:
+			depth++
+		case itemCloseTag, itemSelfClosingTag:
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
 	}
-	return nil
 }
 
-// Ignore everything (xml declarations, etc) before the openning feed tag
+// attributes are the attributes of a tag, keyed on their resolved names
+type attributes map[xmlName]string
+
+// get returns the value of the unprefixed attribute called local
+func (a attributes) get(local string) string {
+	return a[xmlName{"", local}]
+}
+
+// lookup is get, also reporting whether the attribute was there
+func (a attributes) lookup(local string) (string, bool) {
+	value, ok := a[xmlName{"", local}]
+	return value, ok
+}
+
+// readAttributes reads the attributes of the tag just opened, and returns
+// them along with the lexeme that follows them
+func readAttributes(l *nsReader) (attrs attributes, next lexeme) {
+	var name xmlName
+	for next = l.nextItem(); ; next = l.nextItem() {
+		switch next.typ {
+		case itemAttributeName:
+			name = next.name()
+		case itemAttributeValue:
+			if attrs == nil {
+				attrs = make(attributes)
+			}
+			attrs[name] = string(next.val)
+		default:
+			return attrs, next
+		}
+	}
+}
+
+// elements that never have a closing tag in HTML, e.g. <br>
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "param": true, "wbr": true,
+}
+
+// foldMarkup collects everything up to the tag that closes the current
+// element. Unescaped markup (e.g. <br> in a description) is folded back
+// into the text, rather than cutting the text short, and the result is
+// returned as html.
+func foldMarkup(l *nsReader, text lexeme) lexeme {
+	var buf bytes.Buffer
+	writeHtml(&buf, text)
+	pieces := 1 + writeMarkup(l, &buf, l.nextItem(), true)
+	return foldedText(text, &buf, pieces)
+}
+
+// writeMarkup writes lexeme, and everything after it up to the tag that
+// closes the current element, to buf as html. The closing tag is consumed.
+// Returns the number of pieces of text and markup written. Tags are
+// warned about if warn is set, as they're only expected in xhtml content.
+func writeMarkup(l *nsReader, buf *bytes.Buffer, lexeme lexeme, warn bool) (pieces int) {
+	depth := 0
+	inTag := false     // still adding attributes to an open tag
+	skipValue := false // the attribute was a namespace declaration
+	tag := ""
+	for ; !lexeme.isEnd(); lexeme = l.nextItem() {
+		if inTag && lexeme.typ != itemAttributeName && lexeme.typ != itemAttributeValue {
+			inTag = false
+			if lexeme.typ == itemSelfClosingTag {
+				buf.WriteString(" />")
+				if !voidElements[tag] {
+					depth--
+				}
+				continue
+			}
+			buf.WriteString(">")
+		}
+
+		switch lexeme.typ {
+		case itemText, itemHtml:
+			writeHtml(buf, lexeme)
+			pieces++
+		case itemOpenTag:
+			if warn {
+				l.warnf("markup inside text: <%s>", lexeme.val)
+			}
+			buf.WriteString("<" + htmlName(lexeme))
+			pieces++
+			inTag = true
+			tag = strings.ToLower(string(lexeme.val))
+			if !voidElements[tag] {
+				depth++
+			}
+		case itemAttributeName:
+			skipValue = lexeme.ns == nsXMLNS || lexeme.prefix == "" && string(lexeme.val) == "xmlns"
+			if !skipValue {
+				buf.WriteString(" " + qualifiedName(lexeme))
+			}
+		case itemAttributeValue:
+			if !skipValue {
+				buf.WriteString("=\"" + html.EscapeString(string(lexeme.val)) + "\"")
+			}
+		case itemCloseTag:
+			if depth == 0 {
+				return pieces
+			}
+			depth--
+			buf.WriteString("</" + htmlName(lexeme) + ">")
+		}
+	}
+	return pieces
+}
+
+// htmlName is the name to write a tag with as html. Elements in the xhtml
+// namespace lose their prefix, e.g. <xhtml:p> is written as <p>.
+func htmlName(l lexeme) string {
+	if l.ns == nsXHTML {
+		return string(l.val)
+	}
+	return qualifiedName(l)
+}
+
+// foldedText returns text untouched if nothing was folded into it
+func foldedText(text lexeme, buf *bytes.Buffer, pieces int) lexeme {
+	if pieces > 1 {
+		text.typ = itemHtml
+		text.val = buf.Bytes()
+	}
+	return text
+}
+
+// writeHtml writes l as html, escaping it first if it's text
+func writeHtml(buf *bytes.Buffer, l lexeme) {
+	if l.typ == itemText {
+		buf.WriteString(html.EscapeString(string(l.val)))
+	} else {
+		buf.Write(l.val)
+	}
+}
+
+// Ignore everything (xml declarations, etc) before the openning feed tag,
+// noting which format and version the feed is in on the way
 func (r *RssParser) skipUntilFeedTag() {
-	for lexeme := r.lexer.nextItem(); lexeme.val != "channel" && lexeme.val != "feed"; lexeme = r.lexer.nextItem() {
+	lexeme := r.reader.nextItem()
+	for !lexeme.isEnd() {
+		if lexeme.typ != itemOpenTag {
+			lexeme = r.reader.nextItem()
+			continue
+		}
+
+		switch name := lexeme.name(); {
+		case name.local == "rss":
+			// RSS 0.9x and 2.0 give their version on the root element
+			var attrs attributes
+			attrs, lexeme = readAttributes(r.reader)
+			r.feed.Format, r.feed.Version = FormatRSS, attrs.get("version")
+			continue
+		case name.local == "channel":
+			r.feed.Format = FormatRSS
+			return
+		case name == xmlName{nsRDF, "RDF"}:
+			r.feed.Format = FormatRSS
+			switch r.reader.declared("", true) {
+			case nsRSS090:
+				r.feed.Version = "0.90"
+			case nsRSS10:
+				r.feed.Version = "1.0"
+			}
+			return
+		case name.local == "feed":
+			r.feed.Format = FormatAtom
+			switch r.reader.declared(lexeme.prefix, true) {
+			case nsAtom03:
+				r.feed.Version = "0.3"
+			case nsAtom:
+				r.feed.Version = "1.0"
+			case "":
+				// some Atom feeds forget their namespace, and would
+				// otherwise be read as RSS with no channel
+				r.reader.assumeDefault(nsAtom)
+			}
+			return
+		}
+		lexeme = r.reader.nextItem()
 	}
 }
 
@@ -91,411 +437,485 @@ func (r *RssParser) skipUntilFeedTag() {
 // For example, handleFeedTitle assumes lexer has just returned
 // {itemOpenTaq, "Title"}
 
+// populateFeed reads the rest of the document, handing each entry to
+// populateEntry and anything else to the feed handlers
 func (r *RssParser) populateFeed() {
-	r.feed = new(Feed)
-FeedLoop:
-	for {
-		lexeme := r.lexer.nextItem()
-
-		switch lexeme.typ {
-		case itemEOF:
-			break FeedLoop
-		case itemOpenTag:
-			if lexeme.val == "item" {
-				return
-			}
+	for lexeme := r.reader.nextItem(); !lexeme.isEnd(); lexeme = r.reader.nextItem() {
+		if lexeme.typ != itemOpenTag {
+			continue
+		}
+		name := lexeme.name()
+		for entryElements[name] {
+			var entry *Entry
+			entry, name = r.populateEntry()
+			finishEntry(entry)
+			checkDates(entry, r.fetched)
+			r.entries = append(r.entries, entry)
 		}
 
-		handler := r.feedHandlers[lexeme.val]
-		if handler == nil {
+		// only the feed's own children are its metadata, so e.g. the
+		// <title> of its <image> isn't taken for the feed's title
+		extension, ok := r.feedHandlers[name]
+		if !ok || !feedElements[r.reader.parent()] {
 			continue
 		}
 
-		handler(r.lexer, r.feed)
+		r.element = Element{Namespace: extension.name.space, Name: extension.name.local, l: r.reader, base: r.reader.base()}
+		extension.handle(&r.element, r.feed)
+		r.element.Skip()
 	}
 }
 
 // handleFeedTitle handles title tags for the feed secion
-func handleFeedTitle(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleFeedTitle(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		feed.Title = text
 	}
-	feed.Title = lexeme.val
 }
 
-// handleFeedTitle handles link tags for the feed secion
-func handleFeedLink(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
+// handleFeedTitle handles link tags for the feed secion, both RSS's
+// <link>url</link> and Atom's <link rel="alternate" href="url"/>
+func handleFeedLink(e *Element, feed *Feed) {
+	if href, ok := e.LookupAttr("href"); ok {
+		if linkRel(e.Attr("rel")) == "alternate" && feed.Link == "" {
+			feed.Link = e.ResolveURL(href)
+		}
 		return
 	}
-	feed.Link = lexeme.val
+	if text := e.Text(); text != "" {
+		feed.Link = e.ResolveURL(text)
+	}
 }
 
-func handleFeedSubtitle(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleFeedSubtitle(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		feed.Subtitle = text
 	}
-	feed.Subtitle = lexeme.val
 }
 
-func handleFeedCopyright(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleFeedCopyright(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		feed.Copyright = text
 	}
-	feed.Copyright = lexeme.val
 }
 
-func handleFeedAuthor(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleFeedLanguage(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		feed.Language = strings.TrimSpace(text)
+	}
+}
+
+func handleFeedAuthor(e *Element, feed *Feed) {
+	if author, ok := extractPerson(e); ok {
+		feed.Authors = append(feed.Authors, author)
+		if feed.Author == "" {
+			feed.Author = author.String()
+		}
+	}
+}
+
+// handleFeedContributor handles Atom's <contributor>, who is one of the
+// feed's Authors, but never its Author
+func handleFeedContributor(e *Element, feed *Feed) {
+	if contributor, ok := extractPerson(e); ok {
+		feed.Authors = append(feed.Authors, contributor)
 	}
-	feed.Author = lexeme.val
 }
 
-func handleFeedPubDate(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
+func handleFeedPubDate(e *Element, feed *Feed) {
+	text := e.Text()
+	if text == "" {
 		return
 	}
 	var err error
-	feed.PublishDate, err = parseDate(lexeme.val)
-	if err != nil {
-		fmt.Println(err)
+	if feed.PublishDate, err = parseDate(text); err != nil {
+		e.Warnf("%v", err)
 	}
 }
 
-func handleFeedCategory(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+// handleFeedImage handles the feed's <image>, with its url, title, link,
+// width and height. RSS 1.0 also puts an empty <image rdf:resource="..."/>
+// in the channel, pointing to the one outside it, so each field is only
+// set where there's something to set it to.
+func handleFeedImage(e *Element, feed *Feed) {
+	image := feed.Image
+	if image == nil {
+		image = new(Image)
+	}
+	e.Children(func(child *Element) {
+		text := child.Text()
+		if text == "" {
+			return
+		}
+		switch child.Name {
+		case "url":
+			image.Url = child.ResolveURL(text)
+		case "title":
+			image.Title = text
+		case "link":
+			image.Link = child.ResolveURL(text)
+		case "width":
+			image.Width, _ = strconv.Atoi(strings.TrimSpace(text))
+		case "height":
+			image.Height, _ = strconv.Atoi(strings.TrimSpace(text))
+		}
+	})
+	if *image != (Image{}) {
+		feed.Image = image
 	}
-	feed.Category = lexeme.val
 }
 
-func handleFeedGenerator(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+// handleFeedCategory handles RSS's <category>name</category> and Atom's
+// <category term="name"/>
+func handleFeedCategory(e *Element, feed *Feed) {
+	if category, ok := extractCategory(e); ok {
+		feed.Categories = append(feed.Categories, category)
+		if feed.Category == "" {
+			feed.Category = category.Term
+		}
 	}
-	feed.Generator = lexeme.val
 }
 
-func handleFeedLogo(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+// extractCategory reads RSS's <category domain="scheme">term</category> or
+// Atom's <category term="term" scheme="scheme" label="label"/>
+func extractCategory(e *Element) (Category, bool) {
+	if term, ok := e.LookupAttr("term"); ok {
+		return Category{Term: term, Scheme: e.Attr("scheme"), Label: e.Attr("label")}, term != ""
+	}
+	text := e.Text()
+	if text == "" {
+		return Category{}, false
 	}
-	feed.Logo = lexeme.val
+	return Category{Term: text, Scheme: e.Attr("domain")}, true
 }
 
-func handleFeedIcon(l *lexer, feed *Feed) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleFeedGenerator(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		feed.Generator = text
+	}
+}
+
+func handleFeedLogo(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		feed.Logo = e.ResolveURL(text)
+	}
+}
+
+func handleFeedIcon(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		feed.Icon = e.ResolveURL(text)
+	}
+}
+
+// finishEntry fills in what can only be worked out once the whole entry
+// has been read
+func finishEntry(entry *Entry) {
+	if entry.DublinCore != nil {
+		entry.DublinCore.fillEntry(entry)
+	}
+	if entry.Thumbnail == "" {
+		entry.Thumbnail = bestThumbnail(entry)
 	}
-	feed.Icon = lexeme.val
+}
+
+// the elements whose children are the feed's metadata. RSS 1.0 has some,
+// e.g. its <image>, alongside its <channel> rather than in it.
+var feedElements = map[xmlName]bool{
+	{"", "channel"}:  true,
+	{nsAtom, "feed"}: true,
+	{nsRDF, "RDF"}:   true,
+}
+
+// the elements that hold an entry. RSS 1.0's are in its own namespace,
+// which is read as RSS.
+var entryElements = map[xmlName]bool{
+	{"", "item"}:      true,
+	{nsAtom, "entry"}: true,
 }
 
 // Entry handlers
-func (r *RssParser) populateEntries() {
-DocumentLoop:
-	for {
-		entry := new(Entry)
-	EntryLoop:
-		for {
-			lexeme := r.lexer.nextItem()
-
-			switch lexeme.typ {
-			case itemEOF:
-				r.entries = append(r.entries, entry)
-				break DocumentLoop
-			case itemOpenTag:
-				if lexeme.val == "item" {
-					r.entries = append(r.entries, entry)
-					break EntryLoop
-				}
-			}
 
-			handler := r.entryHandlers[lexeme.val]
-			if handler == nil {
-				continue
-			}
+// populateEntry reads the entry whose open tag has just been read, until
+// the entry's element is closed. An entry that runs into the start of
+// another (an unclosed <item>) ends there, and the name of the element
+// that ended it is returned.
+func (r *RssParser) populateEntry() (entry *Entry, next xmlName) {
+	entry = new(Entry)
+	depth := len(r.reader.scopes)
+
+	// RSS 1.0 items are identified by their rdf:about attribute
+	attrs, lexeme := readAttributes(r.reader)
+	entry.Guid = attrs[xmlName{nsRDF, "about"}]
 
-			handler(r.lexer, entry)
+	for ; !lexeme.isEnd() && len(r.reader.scopes) >= depth; lexeme = r.reader.nextItem() {
+		if lexeme.typ != itemOpenTag {
+			continue
 		}
+		name := lexeme.name()
+		if entryElements[name] {
+			return entry, name
+		}
+		// as with the feed, only the entry's own children count, not
+		// e.g. the <title> in a <media:group>
+		extension, ok := r.entryHandlers[name]
+		if !ok || !entryElements[r.reader.parent()] {
+			continue
+		}
+
+		r.element = Element{Namespace: extension.name.space, Name: extension.name.local, l: r.reader, base: r.reader.base()}
+		extension.handle(&r.element, entry)
+		r.element.Skip()
 	}
+	return entry, xmlName{}
 }
 
-func handleEntryTitle(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleEntryTitle(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		entry.Title = text
 	}
-	entry.Title = lexeme.val
 }
 
-func handleEntryLink(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
+func handleEntryLink(e *Element, entry *Entry) {
+	if href, ok := e.LookupAttr("href"); ok {
+		href = e.ResolveURL(href)
+		switch linkRel(e.Attr("rel")) {
+		case "alternate":
+			if entry.Link == "" {
+				entry.Link = href
+			}
+		case "enclosure":
+			addEnclosure(entry, href, e.Attr("type"), e.Attr("length"))
+		case "replies":
+			if entry.Comments == "" {
+				entry.Comments = href
+			}
+		}
 		return
 	}
-	entry.Link = lexeme.val
+	if text := e.Text(); text != "" {
+		entry.Link = e.ResolveURL(text)
+	}
 }
 
-func handleEntrySubtitle(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+// linkRel is the relation of an Atom link, which is "alternate" unless
+// it says otherwise. Relations may also be given as IANA URIs.
+func linkRel(rel string) string {
+	rel = strings.TrimPrefix(rel, "http://www.iana.org/assignments/relation/")
+	if rel == "" {
+		return "alternate"
 	}
-	entry.Subtitle = lexeme.val
+	return rel
 }
 
-func handleEntryGuid(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleEntryAuthor(e *Element, entry *Entry) {
+	if author, ok := extractPerson(e); ok {
+		entry.Authors = append(entry.Authors, author)
+		if entry.Author == "" {
+			entry.Author = author.String()
+		}
 	}
-	entry.Guid = lexeme.val
 }
 
-func handleEntryUpdatedDate(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+// handleEntryContributor handles Atom's <contributor>, as with the feed's
+func handleEntryContributor(e *Element, entry *Entry) {
+	if contributor, ok := extractPerson(e); ok {
+		entry.Authors = append(entry.Authors, contributor)
 	}
-	var err error
-	entry.UpdatedDate, err = parseDate(lexeme.val)
-	if err != nil {
-		fmt.Println(err)
+}
+
+func handleEntryCategory(e *Element, entry *Entry) {
+	if category, ok := extractCategory(e); ok {
+		entry.Categories = append(entry.Categories, category)
 	}
 }
 
-func handleEntrySummary(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+// extractPerson reads an author, whether it's RSS's "email (name)" text
+// or an Atom person construct: <author><name/><email/><uri/></author>
+func extractPerson(e *Element) (person Person, ok bool) {
+	e.Content(func(text string) {
+		person = parsePerson(text)
+	}, func(child *Element) {
+		text := child.Text()
+		if text == "" {
+			return
+		}
+		switch child.Name {
+		case "name":
+			person.Name = text
+		case "email":
+			person.Email = text
+		case "uri", "url": // Atom 0.3 has url
+			person.URI = child.ResolveURL(text)
+		}
+	})
+	return person, person != Person{}
+}
+
+// parsePerson splits RSS's "jo@example.com (Jo Bloggs)" into an email
+// address and a name. Anything else is taken as the name, unless it's
+// just an email address.
+func parsePerson(text string) (person Person) {
+	text = strings.TrimSpace(text)
+	if open := strings.Index(text, " ("); open > 0 && strings.HasSuffix(text, ")") {
+		if email := text[:open]; strings.Contains(email, "@") && !strings.ContainsAny(email, " \t") {
+			return Person{Name: strings.TrimSpace(text[open+2 : len(text)-1]), Email: email}
+		}
 	}
-	if lexeme.typ == itemText {
-		entry.Summary = html.UnescapeString(lexeme.val)
-	} else if lexeme.typ == itemHtml {
-		entry.Summary = lexeme.val
+	if strings.Contains(text, "@") && !strings.ContainsAny(text, " \t") {
+		return Person{Email: text}
 	}
+	return Person{Name: text}
 }
 
-func handleEntryEncoded(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleEntrySubtitle(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		entry.Subtitle = text
 	}
-	if lexeme.typ == itemText {
-		entry.Encoded = html.UnescapeString(lexeme.val)
-	} else if lexeme.typ == itemHtml {
-		entry.Encoded = lexeme.val
+}
+
+func handleEntryGuid(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		entry.Guid = text
 	}
 }
 
-func handleEntryContent(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
+func handleEntryPublishDate(e *Element, entry *Entry) {
+	text := e.Text()
+	if text == "" {
 		return
 	}
-	if lexeme.typ == itemText {
-		entry.Content = html.UnescapeString(lexeme.val)
-	} else if lexeme.typ == itemHtml {
-		entry.Content = lexeme.val
+	var err error
+	if entry.PublishDate, err = parseDate(text); err != nil {
+		e.Warnf("%v", err)
 	}
 }
 
-func handleEntrySource(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
+func handleEntryUpdatedDate(e *Element, entry *Entry) {
+	text := e.Text()
+	if text == "" {
 		return
 	}
-	entry.Source = lexeme.val
+	var err error
+	if entry.UpdatedDate, err = parseDate(text); err != nil {
+		e.Warnf("%v", err)
+	}
 }
 
-func handleEntryComments(l *lexer, entry *Entry) {
-	lexeme := extractTextAndSkip(l)
-	if lexeme == nil {
-		return
+func handleEntrySummary(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		entry.Summary = e.ResolveHtml(text)
 	}
-	entry.Comments = lexeme.val
 }
 
-func handleEntryThumbnail(l *lexer, entry *Entry) {
-	lexeme := l.nextItem() // should be url attribute name
-	lexeme = l.nextItem()  // attribute val
-	entry.Thumbnail = lexeme.val
-	skipUntilTagClose(l)
+func handleEntryEncoded(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		entry.Encoded = e.ResolveHtml(text)
+	}
 }
 
-func handleEntryEnclosure(l *lexer, entry *Entry) {
-EnclosureLoop:
-	for {
-		lexeme := l.nextItem()
-		switch lexeme.val {
-		case "length":
-			lexeme := l.nextItem()
-			entry.Length = lexeme.val
-		case "type":
-			lexeme := l.nextItem()
-			entry.Type = lexeme.val
-		case "url":
-			lexeme := l.nextItem()
-			entry.Url = lexeme.val
+func handleEntryContent(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		entry.Content = e.ResolveHtml(text)
+	}
+}
+
+func handleEntryAtomSummary(e *Element, entry *Entry) {
+	if summary, ok := extractTextConstruct(e); ok {
+		entry.Summary = e.ResolveHtml(summary)
+	}
+}
+
+func handleEntryAtomContent(e *Element, entry *Entry) {
+	if content, ok := extractTextConstruct(e); ok {
+		entry.Content = e.ResolveHtml(content)
+	}
+}
+
+// extractTextConstruct reads an Atom text construct, e.g. <content
+// type="html">, as html. Plain text (the default) is escaped, and xhtml
+// is unwrapped from the <div> it comes in. Content that's elsewhere
+// (<content src="...">) or base64 encoded is passed over. Atom 0.3's
+// mode attribute says whether the content is escaped, base64 or inline
+// xml.
+func extractTextConstruct(e *Element) (string, bool) {
+	if _, ok := e.LookupAttr("src"); ok || e.Attr("mode") == "base64" {
+		return "", false
+	}
+
+	switch typ := e.Attr("type"); {
+	case typ == "xhtml" || typ == "application/xhtml+xml" || e.Attr("mode") == "xml":
+		return e.Xhtml(), true
+	case typ == "html" || typ == "text/html":
+		text := e.Text()
+		return text, text != ""
+	case typ == "" || typ == "text" || strings.HasPrefix(typ, "text/"):
+		text := e.Text()
+		return html.EscapeString(text), text != ""
+	}
+	return "", false
+}
+
+// extractXhtml writes out xhtml content as html, starting from lexeme
+func extractXhtml(l *nsReader, lexeme lexeme) string {
+	var buf bytes.Buffer
+	if lexeme.typ == itemOpenTag && string(lexeme.val) == "div" {
+		for lexeme = l.nextItem(); lexeme.typ == itemAttributeName || lexeme.typ == itemAttributeValue; lexeme = l.nextItem() {
+		}
+		if lexeme.typ != itemSelfClosingTag {
+			writeMarkup(l, &buf, lexeme, false)
 		}
-		if lexeme.typ == itemSelfClosingTag || lexeme.typ == itemCloseTag && lexeme.val == "enclosure" {
-			break EnclosureLoop
+		lexeme = l.nextItem()
+	}
+	writeMarkup(l, &buf, lexeme, false)
+	return buf.String()
+}
+
+// handleEntrySource handles RSS's <source url="...">title</source>, and
+// Atom's <source>, which holds the metadata of the feed an entry was
+// copied from
+func handleEntrySource(e *Element, entry *Entry) {
+	e.Content(func(text string) {
+		entry.Source = text
+	}, func(child *Element) {
+		if child.Namespace == nsAtom && child.Name == "title" {
+			if text := child.Text(); text != "" {
+				entry.Source = text
+			}
 		}
+	})
+	if entry.Source == "" {
+		entry.Source = e.ResolveURL(e.Attr("url"))
+	}
+}
+
+// handleEntryCopyright handles an Atom entry's <rights>
+func handleEntryCopyright(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		entry.Copyright = text
 	}
 }
 
-// date parsing 'borrowed' from mjibson's wondeful goread
-// https://github.com/mjibson/goread/blob/master/goapp/utils.go
-var dateFormats = []string{
-	"01-02-2006",
-	"01/02/2006 15:04:05 MST",
-	"02 Jan 2006 15:04 MST",
-	"02 Jan 2006 15:04:05 -0700",
-	"02 Jan 2006 15:04:05 MST",
-	"02 Jan 2006 15:04:05 UT",
-	"02 Jan 2006",
-	"02-01-2006 15:04:05 MST",
-	"02.01.2006 -0700",
-	"02.01.2006 15:04:05",
-	"02/01/2006 15:04:05",
-	"02/01/2006",
-	"06-1-2 15:04",
-	"06/1/2 15:04",
-	"1/2/2006 15:04:05 MST",
-	"1/2/2006 3:04:05 PM",
-	"15:04 02.01.2006 -0700",
-	"2 Jan 2006 15:04:05 MST",
-	"2 Jan 2006",
-	"2 January 2006 15:04:05 -0700",
-	"2 January 2006",
-	"2006 January 02",
-	"2006-01-02 00:00:00.0 15:04:05.0 -0700",
-	"2006-01-02 15:04",
-	"2006-01-02 15:04:05 -0700",
-	"2006-01-02 15:04:05 MST",
-	"2006-01-02 15:04:05-07:00",
-	"2006-01-02 15:04:05Z",
-	"2006-01-02",
-	"2006-01-02T15:04-07:00",
-	"2006-01-02T15:04:05 -0700",
-	"2006-01-02T15:04:05",
-	"2006-01-02T15:04:05-0700",
-	"2006-01-02T15:04:05-07:00",
-	"2006-01-02T15:04:05-07:00:00",
-	"2006-01-02T15:04:05:-0700",
-	"2006-01-02T15:04:05:00",
-	"2006-01-02T15:04:05Z",
-	"2006-1-02T15:04:05Z",
-	"2006-1-2 15:04:05",
-	"2006-1-2",
-	"2006/01/02",
-	"6-1-2 15:04",
-	"6/1/2 15:04",
-	"Jan 02 2006 03:04:05PM",
-	"Jan 2, 2006 15:04:05 MST",
-	"Jan 2, 2006 3:04:05 PM MST",
-	"January 02, 2006 03:04 PM",
-	"January 02, 2006 15:04",
-	"January 02, 2006 15:04:05 MST",
-	"January 02, 2006",
-	"January 2, 2006 03:04 PM",
-	"January 2, 2006 15:04:05 MST",
-	"January 2, 2006 15:04:05",
-	"January 2, 2006",
-	"January 2, 2006, 3:04 p.m.",
-	"Mon 02 Jan 2006 15:04:05 -0700",
-	"Mon 2 Jan 2006 15:04:05 MST",
-	"Mon Jan 2 15:04 2006",
-	"Mon Jan 2 15:04:05 2006 MST",
-	"Mon, 02 Jan 06 15:04:05 MST",
-	"Mon, 02 Jan 2006 15:04 -0700",
-	"Mon, 02 Jan 2006 15:04 MST",
-	"Mon, 02 Jan 2006 15:04:05 --0700",
-	"Mon, 02 Jan 2006 15:04:05 -07",
-	"Mon, 02 Jan 2006 15:04:05 -0700",
-	"Mon, 02 Jan 2006 15:04:05 -07:00",
-	"Mon, 02 Jan 2006 15:04:05 00",
-	"Mon, 02 Jan 2006 15:04:05 MST -0700",
-	"Mon, 02 Jan 2006 15:04:05 MST",
-	"Mon, 02 Jan 2006 15:04:05 MST-07:00",
-	"Mon, 02 Jan 2006 15:04:05 UT",
-	"Mon, 02 Jan 2006 15:04:05 Z",
-	"Mon, 02 Jan 2006 15:04:05",
-	"Mon, 02 Jan 2006 15:04:05MST",
-	"Mon, 02 Jan 2006 3:04:05 PM MST",
-	"Mon, 02 Jan 2006",
-	"Mon, 02 January 2006",
-	"Mon, 2 Jan 06 15:04:05 -0700",
-	"Mon, 2 Jan 06 15:04:05 MST",
-	"Mon, 2 Jan 15:04:05 MST",
-	"Mon, 2 Jan 2006 15:04",
-	"Mon, 2 Jan 2006 15:04:05 -0700 MST",
-	"Mon, 2 Jan 2006 15:04:05 -0700",
-	"Mon, 2 Jan 2006 15:04:05 MST",
-	"Mon, 2 Jan 2006 15:04:05 UT",
-	"Mon, 2 Jan 2006 15:04:05",
-	"Mon, 2 Jan 2006 15:04:05-0700",
-	"Mon, 2 Jan 2006 15:04:05MST",
-	"Mon, 2 Jan 2006 15:4:5 MST",
-	"Mon, 2 Jan 2006",
-	"Mon, 2 Jan 2006, 15:04 -0700",
-	"Mon, 2 January 2006 15:04:05 -0700",
-	"Mon, 2 January 2006 15:04:05 MST",
-	"Mon, 2 January 2006, 15:04 -0700",
-	"Mon, 2 January 2006, 15:04:05 MST",
-	"Mon, 2, Jan 2006 15:4",
-	"Mon, Jan 2 2006 15:04:05 -0700",
-	"Mon, Jan 2 2006 15:04:05 -700",
-	"Mon, January 02, 2006, 15:04:05 MST",
-	"Mon, January 2 2006 15:04:05 -0700",
-	"Mon,02 Jan 2006 15:04:05 -0700",
-	"Mon,02 January 2006 14:04:05 MST",
-	"Monday, 02 January 2006 15:04:05 -0700",
-	"Monday, 02 January 2006 15:04:05 MST",
-	"Monday, 02 January 2006 15:04:05",
-	"Monday, 2 Jan 2006 15:04:05 -0700",
-	"Monday, 2 Jan 2006 15:04:05 MST",
-	"Monday, 2 January 2006 15:04:05 -0700",
-	"Monday, 2 January 2006 15:04:05 MST",
-	"Monday, January 02, 2006",
-	"Monday, January 2, 2006 03:04 PM",
-	"Monday, January 2, 2006 15:04:05 MST",
-	"Monday, January 2, 2006",
-	"Updated January 2, 2006",
-	"mon,2 Jan 2006 15:04:05 MST",
-	time.ANSIC,
-	time.RFC1123,
-	time.RFC1123Z,
-	time.RFC3339,
-	time.RFC822,
-	time.RFC822Z,
-	time.RFC850,
-	time.RubyDate,
-	time.UnixDate,
-}
-
-func parseDate(dateStr string) (t time.Time, err error) {
-	d := strings.TrimSpace(dateStr)
-	if d == "" {
-		err = fmt.Errorf("Empty date string")
+func handleEntryComments(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		entry.Comments = e.ResolveURL(text)
+	}
+}
+
+// handleEntryEnclosure handles RSS's <enclosure url="..." length="..."
+// type="..."/>, of which an entry may have several
+func handleEntryEnclosure(e *Element, entry *Entry) {
+	addEnclosure(entry, e.ResolveURL(e.Attr("url")), e.Attr("type"), e.Attr("length"))
+}
+
+// addEnclosure adds an enclosure to the entry, the first of which also
+// sets its Url, Type and Length
+func addEnclosure(entry *Entry, url, typ, length string) {
+	if url == "" {
 		return
 	}
-	for _, f := range dateFormats {
-		if t, err = time.Parse(f, d); err == nil {
-			return
-		}
+	size, _ := strconv.ParseInt(strings.TrimSpace(length), 10, 64)
+	entry.Enclosures = append(entry.Enclosures, Enclosure{URL: url, Type: typ, Length: size})
+	if entry.Url == "" {
+		entry.Url, entry.Type, entry.Length = url, typ, length
 	}
-	err = fmt.Errorf("Could not parse date: %v", dateStr)
-	return
 }
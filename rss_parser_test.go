@@ -1,10 +1,16 @@
 package rss
 
 import (
+	"bytes"
 	"fmt"
 	"log"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
+	"unicode/utf16"
 )
 
 func Test_RssParserHookup(t *testing.T) {
@@ -41,18 +47,22 @@ func Test_SuttersMill(t *testing.T) {
 	f.Link = "http://herbsutter.com"
 	f.Subtitle = "Herb Sutter on software, hardware, and concurrency"
 	f.Generator = "http://wordpress.com/"
+	f.Image = &Image{
+		Url:   "http://0.gravatar.com/blavatar/4554b8d24c7f200dc5e2e1b18db1893f?s=96&d=http%3A%2F%2Fs2.wp.com%2Fi%2Fbuttonw-com.png\n",
+		Title: "Sutter's Mill",
+		Link:  "http://herbsutter.com",
+	}
 
 	var e *Entry = new(Entry)
 	e.Title = "GotW #7b: Minimizing Compile-Time Dependencies, Part 2\n"
 	e.Link = "http://herbsutter.com/2013/08/19/gotw-7b-minimizing-compile-time-dependencies-part-2/\n"
 	e.Guid = "http://herbsutter.com/?p=2294"
+	e.Author = "Herb Sutter"
 	e.Summary = `Now that the unnecessary headers have been removed, it&#8217;s time for Phase 2: How can you limit dependencies on the internals of a class? Problem JG Questions 1. What does private mean for a class member in C++? 2. Why does changing the private members of a type cause a recompilation? Guru Question 3. Below [&#8230;]<img alt="" border="0" src="http://stats.wordpress.com/b.gif?host=herbsutter.com&#038;blog=3379246&#038;post=2294&#038;subd=herbsutter&#038;ref=&#038;feed=1" width="1" height="1" />
 `
 	e.Encoded = `<p><span style="color:#5a5a5a;"><em>Now that the unnecessary headers have been removed, it&#8217;s time for Phase 2: How can you limit dependencies on the internals of a class?</em></span> </p> <h1>Problem<br /> </h1> <h2>JG Questions<br /> </h2> <p>1. What does <span style="color:#2e74b5;">private</span> mean for a class member in C++? </p> <p>2. Why does changing the private members of a type cause a recompilation? </p> <h2>Guru Question<br /> </h2> <p>3. Below is how the header from the previous Item looks after the initial cleanup pass. What further <span style="color:#2e74b5;">#include</span>s could be removed if we made some suitable changes, and how? </p> ...
 `
-	e.Content = `<p><span style="color:#5a5a5a;"><em>Now that the unnecessary headers have been removed, it&#8217;s time for Phase 2: How can you limit dependencies on the internals of a class?</em></span> </p> <h1>Problem<br /> </h1> <h2>JG Questions<br /> </h2> <p>1. What does <span style="color:#2e74b5;">private</span> mean for a class member in C++? </p> <p>2. Why does changing the private members of a type cause a recompilation? </p> <h2>Guru Question<br /> </h2> <p>3. Below is how the header from the previous Item looks after the initial cleanup pass. What further <span style="color:#2e74b5;">#include</span>s could be removed if we made some suitable changes, and how? </p> ...
-`
-	e.Comments = "8"
+	e.Comments = "http://herbsutter.com/2013/08/19/gotw-7b-minimizing-compile-time-dependencies-part-2/#comments\n"
 
 	entries := make([]*Entry, 0, 1)
 	entries = append(entries, e)
@@ -64,6 +74,11 @@ func Test_ProgrammingReddit(t *testing.T) {
 	f.Title = "programming"
 	f.Link = "http://www.reddit.com/r/programming/"
 	f.Subtitle = "Computer Programming"
+	f.Image = &Image{
+		Url:   "http://static.reddit.com/reddit_programming.png",
+		Title: "programming",
+		Link:  "http://www.reddit.com/r/programming/",
+	}
 
 	var e *Entry = new(Entry)
 	e.Title = "Which browsers crash the most?"
@@ -81,12 +96,13 @@ func Test_EricLipert(t *testing.T) {
 	var f *Feed = new(Feed)
 	f.Title = "Fabulous Adventures In Coding"
 	f.Link = "http://blogs.msdn.com/b/ericlippert/"
-	f.Subtitle = "Eric Lippert&amp;#39;s Erstwhile Blog"
+	f.Subtitle = "Eric Lippert&#39;s Erstwhile Blog"
 
 	var e *Entry = new(Entry)
 	e.Title = "A new fabulous adventure"
 	e.Link = "http://blogs.msdn.com/b/ericlippert/archive/2012/11/29/a-new-fabulous-adventure.aspx"
 	e.Guid = "91d46819-8472-40ad-a661-2c78acb4018c:10369420"
+	e.Author = "Eric Lippert"
 	e.Summary = `<div class="mine">
 <p>Tomorrow, the 30th of November, 2012, is the first day of my fifth decade here on Earth, and my last day at Microsoft. (*)</p>
 <p>(*) That timing is not coincidental.</p>
@@ -98,6 +114,1442 @@ func Test_EricLipert(t *testing.T) {
 	testContent("Eric Lippert", ericLippertContent, f, entries, t)
 }
 
+func Test_Atom(t *testing.T) {
+	var f *Feed = new(Feed)
+	f.Title = "Example Feed"
+	f.Link = "http://example.org/"
+	f.Subtitle = "A subtitle."
+	f.Copyright = "Copyright (c) 2003, Mark Pilgrim"
+	f.Author = "John Doe"
+	f.Category = "examples"
+	f.Generator = "Example Toolkit"
+
+	var first *Entry = new(Entry)
+	first.Title = "Atom-Powered Robots Run Amok"
+	first.Link = "http://example.org/2003/12/13/atom03"
+	first.Guid = "urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a"
+	first.Author = "Jane Doe"
+	first.Summary = "Some text &amp; more."
+	first.Content = `<p>Robots &amp; androids are <em>running amok</em>.</p>`
+	first.Url = "http://example.org/audio/ph34r_my_podcast.mp3"
+	first.Type = "audio/mpeg"
+	first.Length = "1337"
+	first.Comments = "http://example.org/2003/12/13/atom03/comments"
+
+	var second *Entry = new(Entry)
+	second.Title = "Second"
+	second.Link = "http://example.org/2003/12/14/second"
+	second.Guid = "urn:uuid:2"
+	second.Content = "<b>bold</b> move"
+	second.Source = "Elsewhere"
+
+	entries := []*Entry{first, second}
+	testContent("Atom", atomContent, f, entries, t)
+
+	_, actEs := parseFeed("Atom", atomContent, t)
+	if len(actEs) != 2 {
+		t.Fatalf("entry count (%d) not as expected (2)", len(actEs))
+	}
+	cmpTime("entry PublishDate", time.Date(2003, 12, 13, 8, 29, 29, 0, time.FixedZone("", -4*60*60)).UTC(), actEs[0].PublishDate.UTC(), t)
+	cmpTime("entry UpdatedDate", time.Date(2003, 12, 13, 18, 30, 2, 0, time.UTC), actEs[0].UpdatedDate.UTC(), t)
+}
+
+func Test_Rss10(t *testing.T) {
+	var f *Feed = new(Feed)
+	f.Title = "XML.com"
+	f.Link = "http://xml.com/pub"
+	f.Subtitle = "XML.com features a rich mix of information and services for the XML community."
+	f.Image = &Image{
+		Url:   "http://xml.com/universal/images/xml_tiny.gif",
+		Title: "XML.com logo",
+		Link:  "http://www.xml.com",
+	}
+
+	var first *Entry = new(Entry)
+	first.Title = "Processing Inclusions with XSLT"
+	first.Link = "http://xml.com/pub/2000/08/09/xslt/xslt.html"
+	first.Guid = "http://xml.com/pub/2000/08/09/xslt/xslt.html"
+	first.Summary = "Processing document inclusions with general XML tools can be problematic."
+
+	var second *Entry = new(Entry)
+	second.Title = "Putting RDF to Work"
+	second.Link = "http://xml.com/pub/2000/08/09/rdfdb/index.html"
+	second.Guid = "http://xml.com/pub/2000/08/09/rdfdb/"
+
+	entries := []*Entry{first, second}
+	testContent("RSS 1.0", rss10Content, f, entries, t)
+
+	feed, actEs := parseFeed("RSS 1.0", rss10Content, t)
+	if len(actEs) != 2 {
+		t.Fatalf("entry count (%d) not as expected (2)", len(actEs))
+	}
+	cmpTime("feed PublishDate", time.Date(2000, 8, 9, 4, 0, 0, 0, time.UTC), feed.PublishDate.UTC(), t)
+	cmpTime("entry UpdatedDate", time.Date(2000, 8, 9, 11, 22, 33, 0, time.UTC), actEs[0].UpdatedDate.UTC(), t)
+}
+
+func Test_AtomTextConstructs(t *testing.T) {
+	content := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:x="http://www.w3.org/1999/xhtml">
+<entry><summary type="text">1 &lt; 2</summary><content type="xhtml"><x:div><x:p>a<x:br/>b</x:p></x:div></content></entry>
+<entry><summary type="html">1 &lt;b&gt;2&lt;/b&gt;</summary><content type="text/plain"><![CDATA[<raw>]]></content></entry>
+<entry><content type="image/png" src="http://example.org/a.png"/><title>after</title></entry>
+</feed>`
+
+	_, entries := parseFeed("text constructs", content, t)
+	if len(entries) != 3 {
+		t.Fatalf("entry count (%d) not as expected (3)", len(entries))
+	}
+	cmpStr("entry Summary", "1 &lt; 2", entries[0].Summary, t)
+	cmpStr("entry Content", "<p>a<br />b</p>", entries[0].Content, t)
+	cmpStr("entry Summary", "1 <b>2</b>", entries[1].Summary, t)
+	cmpStr("entry Content", "&lt;raw&gt;", entries[1].Content, t)
+	cmpStr("entry Content", "", entries[2].Content, t)
+	cmpStr("entry Title", "after", entries[2].Title, t)
+}
+
+func Test_JsonFeed(t *testing.T) {
+	feed, entries, err := NewJsonFeedParser("JSON Feed", strings.NewReader(jsonFeedContent)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmpStr("feed Title", "My Example Feed", feed.Title, t)
+	cmpStr("feed Link", "https://example.org/", feed.Link, t)
+	cmpStr("feed Subtitle", "Notes & links", feed.Subtitle, t)
+	cmpStr("feed Author", "Brent", feed.Author, t)
+	cmpStr("feed Logo", "https://example.org/icon.png", feed.Logo, t)
+	cmpStr("feed Icon", "https://example.org/favicon.ico", feed.Icon, t)
+	cmpStr("feed Language", "en-US", feed.Language, t)
+
+	if len(entries) != 2 {
+		t.Fatalf("entry count (%d) not as expected (2)", len(entries))
+	}
+	first := entries[0]
+	cmpStr("entry Guid", "2", first.Guid, t)
+	cmpStr("entry Link", "https://example.org/second-item", first.Link, t)
+	cmpStr("entry Title", "Second", first.Title, t)
+	cmpStr("entry Content", "<p>Hello, world!</p>", first.Content, t)
+	cmpStr("entry Summary", "A greeting &amp; a wave", first.Summary, t)
+	cmpStr("entry Author", "Manton", first.Author, t)
+	cmpStr("entry Thumbnail", "https://example.org/second.png", first.Thumbnail, t)
+	cmpStr("entry Url", "https://example.org/second.m4a", first.Url, t)
+	cmpStr("entry Type", "audio/x-m4a", first.Type, t)
+	cmpStr("entry Length", "89970236", first.Length, t)
+	cmpEnclosures("entry Enclosures", []Enclosure{{"https://example.org/second.m4a", "audio/x-m4a", 89970236}, {"https://example.org/second.mp3", "audio/mpeg", 0}}, first.Enclosures, t)
+	cmpCategories("entry Categories", []Category{{"greetings", "", ""}, {"examples", "", ""}}, first.Categories, t)
+	cmpTime("entry PublishDate", time.Date(2010, 2, 7, 14, 4, 0, 0, time.UTC), first.PublishDate.UTC(), t)
+	cmpTime("entry UpdatedDate", time.Date(2010, 2, 8, 9, 0, 0, 0, time.UTC), first.UpdatedDate.UTC(), t)
+
+	second := entries[1]
+	cmpStr("entry Guid", "https://example.org/first-item", second.Guid, t)
+	cmpStr("entry Link", "https://elsewhere.example.com/", second.Link, t)
+	cmpStr("entry Content", "1 &lt; 2 &amp; 3", second.Content, t)
+	cmpStr("entry Author", "Brent", second.Author, t)
+	cmpPeople("entry Authors", []Person{{"Brent", "", "https://example.org/brent"}}, second.Authors, t)
+	cmpTime("entry UpdatedDate", second.PublishDate, second.UpdatedDate, t)
+
+	second.Authors[0].Name = "Someone else"
+	cmpStr("feed Authors", "Brent", feed.Authors[0].Name, t)
+}
+
+func Test_JsonFeedVersion(t *testing.T) {
+	_, _, err := NewJsonFeedParser("not a feed", strings.NewReader(`{"title": "x", "items": []}`)).Parse()
+	if err == nil {
+		t.Error("expected an error for JSON without a JSON Feed version")
+	}
+	_, _, err = NewJsonFeedParser("broken", strings.NewReader(`{"version": `)).Parse()
+	if err == nil {
+		t.Error("expected an error for broken JSON")
+	}
+}
+
+func Test_JsonFeedDateWarnings(t *testing.T) {
+	content := `{"version": "https://jsonfeed.org/version/1.1", "title": "Dates", "items": [
+{"id": "1", "date_published": "sometime last week"}]}`
+
+	// only lenient parsers report them
+	if _, _, err := NewJsonFeedParser("JSON dates", strings.NewReader(content)).Parse(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	parser := NewJsonFeedParser("JSON dates", strings.NewReader(content))
+	parser.SetLenient(true)
+	_, entries, err := parser.Parse()
+	warnings, ok := err.(LexErrorList)
+	if !ok || len(warnings) != 1 {
+		t.Fatalf("warnings (%v) not as expected (1)", err)
+	}
+	cmpStr("warning", "Could not parse date: sometime last week", warnings[0].Msg, t)
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	cmpStr("DateSource", DateFirstSeen, entries[0].DateSource, t)
+}
+
+func Test_IsJsonFeed(t *testing.T) {
+	tests := []struct {
+		content, contentType string
+		expected             bool
+	}{
+		{jsonFeedContent, "application/feed+json", true},
+		{jsonFeedContent, "application/json; charset=utf-8", true},
+		{jsonFeedContent, "", true},
+		{jsonFeedContent, "text/plain", true},
+		{"\xef\xbb\xbf \r\n\t{}", "", true},
+		{suttersMillContent, "", false},
+		{suttersMillContent, "application/rss+xml", false},
+		{"{}", "text/xml", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		if actual := isJsonFeed([]byte(test.content), test.contentType); actual != test.expected {
+			t.Errorf("isJsonFeed(%.20q, %q) = %v, expected %v", test.content, test.contentType, actual, test.expected)
+		}
+	}
+}
+
+func Test_ParseFeedPicksFormat(t *testing.T) {
+	rss := new(RssEngine)
+	feed, _, err := rss.parseFeed("JSON Feed", strings.NewReader(jsonFeedContent), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmpStr("feed Title", "My Example Feed", feed.Title, t)
+
+	feed, _, err = rss.parseFeed("Atom", strings.NewReader(atomContent), "application/atom+xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmpStr("feed Title", "Example Feed", feed.Title, t)
+}
+
+func Test_ParseFeedFormats(t *testing.T) {
+	tests := []struct {
+		name, content, contentType string
+		format, version, title     string
+	}{
+		{"RSS 0.90", `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://my.netscape.com/rdf/simple/0.9/"><channel><title>0.90</title></channel></rdf:RDF>`, "", FormatRSS, "0.90", "0.90"},
+		{"RSS 0.91", `<?xml version="1.0"?><!DOCTYPE rss SYSTEM "http://my.netscape.com/publish/formats/rss-0.91.dtd"><rss version="0.91"><channel><title>0.91</title></channel></rss>`, "", FormatRSS, "0.91", "0.91"},
+		{"RSS 0.92", `<rss version="0.92"><channel><title>0.92</title></channel></rss>`, "", FormatRSS, "0.92", "0.92"},
+		{"RSS 2.0", suttersMillContent, "application/rss+xml", FormatRSS, "2.0", "Sutter's Mill"},
+		{"RSS without version", `<rss><channel><title>?</title></channel></rss>`, "", FormatRSS, "", "?"},
+		{"RSS 1.0", rss10Content, "application/rdf+xml", FormatRSS, "1.0", "XML.com"},
+		{"Atom 0.3", atom03Content, "", FormatAtom, "0.3", "dive into mark"},
+		{"Atom 1.0", atomContent, "application/atom+xml", FormatAtom, "1.0", "Example Feed"},
+		{"Atom without namespace", atomWithoutNamespaceContent, "", FormatAtom, "", "No namespace"},
+		{"JSON Feed 1.0", `{"version": "https://jsonfeed.org/version/1", "title": "1.0"}`, "", FormatJSON, "1.0", "1.0"},
+		{"JSON Feed 1.1", jsonFeedContent, "application/feed+json", FormatJSON, "1.1", "My Example Feed"},
+	}
+	for _, test := range tests {
+		feed, _, err := ParseFeed(test.name, []byte(test.content), test.contentType)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		cmpStr(test.name+" feed Format", test.format, feed.Format, t)
+		cmpStr(test.name+" feed Version", test.version, feed.Version, t)
+		cmpStr(test.name+" feed Title", test.title, feed.Title, t)
+	}
+
+	if _, _, err := ParseFeed("HTML", []byte("<html><body>Not a feed</body></html>"), "text/html"); err == nil {
+		t.Error("expected an error for a page with no feed in it")
+	}
+}
+
+func Test_AtomWithoutNamespace(t *testing.T) {
+	feed, entries := parseFeed("Atom without namespace", atomWithoutNamespaceContent, t)
+	cmpStr("feed Format", FormatAtom, feed.Format, t)
+	cmpStr("feed Title", "No namespace", feed.Title, t)
+	cmpStr("feed Link", "http://example.org/", feed.Link, t)
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	cmpStr("entry Title", "Still Atom", entries[0].Title, t)
+	cmpStr("entry Link", "http://example.org/still-atom", entries[0].Link, t)
+	cmpStr("entry Guid", "urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a", entries[0].Guid, t)
+	cmpTime("entry PublishDate", time.Date(2003, 12, 13, 18, 30, 2, 0, time.UTC), entries[0].PublishDate, t)
+}
+
+func Test_Atom03(t *testing.T) {
+	var f *Feed = new(Feed)
+	f.Title = "dive into mark"
+	f.Link = "http://diveintomark.org/"
+	f.Subtitle = "A lot of effort went into making this effortless"
+	f.Copyright = "Copyright (c) 2003, Mark Pilgrim"
+	f.Author = "Mark Pilgrim"
+
+	var first *Entry = new(Entry)
+	first.Title = "Atom 0.3 snapshot"
+	first.Link = "http://diveintomark.org/2003/12/13/atom03"
+	first.Guid = "tag:diveintomark.org,2003:3.2397"
+	first.Summary = "The snapshot"
+	first.Content = "<p>Escaped <em>html</em></p>"
+
+	var second *Entry = new(Entry)
+	second.Title = "Inline"
+	second.Guid = "tag:diveintomark.org,2003:3.2398"
+	second.Content = "<p>Inline <b>xml</b></p>"
+
+	entries := []*Entry{first, second}
+	testContent("Atom 0.3", atom03Content, f, entries, t)
+
+	feed, actEs := parseFeed("Atom 0.3", atom03Content, t)
+	if len(actEs) != 2 {
+		t.Fatalf("entry count (%d) not as expected (2)", len(actEs))
+	}
+	cmpTime("feed PublishDate", time.Date(2003, 12, 13, 18, 30, 2, 0, time.UTC), feed.PublishDate.UTC(), t)
+	cmpTime("entry PublishDate", time.Date(2003, 12, 13, 8, 29, 29, 0, time.FixedZone("", -4*60*60)).UTC(), actEs[0].PublishDate.UTC(), t)
+	cmpTime("entry UpdatedDate", time.Date(2003, 12, 13, 18, 30, 2, 0, time.UTC), actEs[0].UpdatedDate.UTC(), t)
+}
+
+func Test_NestedElements(t *testing.T) {
+	content := `<rss xmlns:media="http://search.yahoo.com/mrss/"><channel>
+<title>Channel</title>
+<link>http://example.com/</link>
+<image><url>http://example.com/logo.png</url><title>Logo</title><link>http://example.com/home</link><width>88</width><height> 31 </height><description>Ignored</description></image>
+<textinput><title>Search</title><description>Search this site</description><name>q</name><link>http://example.com/search</link></textinput>
+<item>
+<title>Item</title>
+<media:group><title>Group</title><media:content url="http://example.com/a.mp4"><title>Content</title></media:content></media:group>
+<source url="http://example.org/feed"><title>Not an item title</title></source>
+<unknown><link>http://example.com/unknown</link></unknown>
+</item>
+</channel></rss>`
+
+	feed, entries := parseFeed("nested", content, t)
+	cmpStr("feed Title", "Channel", feed.Title, t)
+	cmpStr("feed Link", "http://example.com/", feed.Link, t)
+	cmpStr("feed Subtitle", "", feed.Subtitle, t)
+	cmpImage("feed Image", &Image{"http://example.com/logo.png", "Logo", "http://example.com/home", 88, 31}, feed.Image, t)
+
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	cmpStr("entry Title", "Item", entries[0].Title, t)
+	cmpStr("entry Link", "", entries[0].Link, t)
+}
+
+func Test_CategoriesAuthorsAndEnclosures(t *testing.T) {
+	content := `<rss><channel>
+<title>Podcast</title>
+<category>Technology</category>
+<category domain="http://example.com/genres">Podcasts</category>
+<managingEditor>editor@example.com (Ed Itor)</managingEditor>
+<item>
+<title>Episode 1</title>
+<author>host@example.com (The Host)</author>
+<category>Go</category>
+<category domain="http://example.com/tags">parsing</category>
+<enclosure url="http://example.com/ep1.mp3" length="12345678" type="audio/mpeg"/>
+<enclosure url="http://example.com/ep1.ogg" length="" type="audio/ogg"></enclosure>
+<enclosure length="1" type="audio/mpeg"/>
+</item>
+</channel></rss>`
+
+	feed, entries := parseFeed("podcast", content, t)
+	cmpStr("feed Category", "Technology", feed.Category, t)
+	cmpCategories("feed Categories", []Category{{"Technology", "", ""}, {"Podcasts", "http://example.com/genres", ""}}, feed.Categories, t)
+	cmpStr("feed Author", "Ed Itor", feed.Author, t)
+	cmpPeople("feed Authors", []Person{{"Ed Itor", "editor@example.com", ""}}, feed.Authors, t)
+
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	entry := entries[0]
+	cmpStr("entry Author", "The Host", entry.Author, t)
+	cmpPeople("entry Authors", []Person{{"The Host", "host@example.com", ""}}, entry.Authors, t)
+	cmpCategories("entry Categories", []Category{{"Go", "", ""}, {"parsing", "http://example.com/tags", ""}}, entry.Categories, t)
+	cmpEnclosures("entry Enclosures", []Enclosure{{"http://example.com/ep1.mp3", "audio/mpeg", 12345678}, {"http://example.com/ep1.ogg", "audio/ogg", 0}}, entry.Enclosures, t)
+	cmpStr("entry Url", "http://example.com/ep1.mp3", entry.Url, t)
+	cmpStr("entry Type", "audio/mpeg", entry.Type, t)
+	cmpStr("entry Length", "12345678", entry.Length, t)
+}
+
+func Test_AtomCategoriesAndContributors(t *testing.T) {
+	content := `<feed xmlns="http://www.w3.org/2005/Atom">
+<category term="examples" scheme="http://example.org/scheme" label="Examples"/>
+<author><name>John Doe</name><email>john@example.org</email></author>
+<contributor><name>Sam</name></contributor>
+<entry>
+<contributor><name>Alex</name></contributor>
+<author><email>jane@example.org</email><uri>http://example.org/jane</uri></author>
+<author><name>Second Author</name></author>
+<category term="robots"/>
+<category term=""/>
+<link rel="enclosure" href="http://example.org/a.mp3" type="audio/mpeg" length="10"/>
+<link rel="enclosure" href="http://example.org/b.mp3" type="audio/mpeg"/>
+</entry>
+</feed>`
+
+	feed, entries := parseFeed("Atom categories", content, t)
+	cmpCategories("feed Categories", []Category{{"examples", "http://example.org/scheme", "Examples"}}, feed.Categories, t)
+	cmpStr("feed Author", "John Doe", feed.Author, t)
+	cmpPeople("feed Authors", []Person{{"John Doe", "john@example.org", ""}, {"Sam", "", ""}}, feed.Authors, t)
+
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	entry := entries[0]
+	cmpStr("entry Author", "jane@example.org", entry.Author, t)
+	cmpPeople("entry Authors", []Person{{"Alex", "", ""}, {"", "jane@example.org", "http://example.org/jane"}, {"Second Author", "", ""}}, entry.Authors, t)
+	cmpCategories("entry Categories", []Category{{"robots", "", ""}}, entry.Categories, t)
+	cmpEnclosures("entry Enclosures", []Enclosure{{"http://example.org/a.mp3", "audio/mpeg", 10}, {"http://example.org/b.mp3", "audio/mpeg", 0}}, entry.Enclosures, t)
+}
+
+func Test_ParsePerson(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Person
+	}{
+		{"jo@example.com (Jo Bloggs)", Person{Name: "Jo Bloggs", Email: "jo@example.com"}},
+		{" jo@example.com ", Person{Email: "jo@example.com"}},
+		{"Jo Bloggs", Person{Name: "Jo Bloggs"}},
+		{"Jo (the editor)", Person{Name: "Jo (the editor)"}},
+		{"Jo at jo@example.com", Person{Name: "Jo at jo@example.com"}},
+	}
+	for _, test := range tests {
+		if actual := parsePerson(test.text); actual != test.expected {
+			t.Errorf("parsePerson(%q) = %+v, expected %+v", test.text, actual, test.expected)
+		}
+	}
+}
+
+func Test_Podcast(t *testing.T) {
+	feed, entries := parseFeed("Podcasting 2.0", podcastContent, t)
+	cmpStr("feed Title", "Podcasting 2.0 Namespace Example", feed.Title, t)
+	if feed.Podcast == nil {
+		t.Fatal("no podcast elements on the feed")
+	}
+	podcast := feed.Podcast
+	cmpStr("podcast Image", "https://example.com/images/pci_avatar-massive.jpg", podcast.Image, t)
+	cmpStr("podcast Guid", "917393e3-1b1e-5cef-ace4-edaa54e1f810", podcast.Guid, t)
+	if podcast.Explicit != ExplicitNo {
+		t.Errorf("podcast Explicit (%d) not as expected (%d)", podcast.Explicit, ExplicitNo)
+	}
+	expCategories := []PodcastCategory{
+		{Name: "Technology", Subcategories: []PodcastCategory{{Name: "Podcasting"}}},
+		{Name: "News", Subcategories: []PodcastCategory{{Name: "Tech News"}, {Name: "Politics"}}},
+	}
+	if !reflect.DeepEqual(expCategories, podcast.Categories) {
+		t.Errorf("podcast Categories (%+v) not as expected (%+v)", podcast.Categories, expCategories)
+	}
+	expPersons := []PodcastPerson{{Name: "Alice Brown", Role: "host", Group: "cast", Img: "http://example.com/images/alicebrown.jpg", Href: "https://www.wikipedia/alicebrown"}}
+	if !reflect.DeepEqual(expPersons, podcast.Persons) {
+		t.Errorf("podcast Persons (%+v) not as expected (%+v)", podcast.Persons, expPersons)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("entry count (%d) not as expected (2)", len(entries))
+	}
+	episode := entries[0].Podcast
+	if episode == nil {
+		t.Fatal("no podcast elements on the first entry")
+	}
+	expEpisode := PodcastEpisode{
+		Duration: 1*time.Hour + 2*time.Minute + 3*time.Second,
+		Episode:  3,
+		Season:   2,
+		Explicit: ExplicitYes,
+		Image:    "https://example.com/ep0003/artMd.jpg",
+		Transcripts: []PodcastTranscript{
+			{URL: "https://example.com/ep3/transcript.txt", Type: "text/plain"},
+			{URL: "https://example.com/ep3/transcript.vtt", Type: "text/vtt", Language: "es", Rel: "captions"},
+		},
+		Chapters: &PodcastChapters{URL: "https://example.com/ep3_chapters.json", Type: "application/json"},
+		Persons: []PodcastPerson{
+			{Name: "Jane Doe", Role: "guest", Group: "cast", Href: "https://www.wikipedia/janedoe", Img: "http://example.com/images/janedoe.jpg"},
+			{Name: "Becky Smith", Group: "visuals", Role: "cover art designer", Href: "https://www.wikipedia/beckysmith"},
+		},
+	}
+	if !reflect.DeepEqual(&expEpisode, episode) {
+		t.Errorf("entry Podcast (%+v) not as expected (%+v)", *episode, expEpisode)
+	}
+	cmpStr("entry Title", "Episode 3 - The Future", entries[0].Title, t)
+	cmpStr("entry Url", "https://example.com/file-03.mp3", entries[0].Url, t)
+
+	// the second episode's duration is in seconds, and it has nothing else
+	if entries[1].Podcast == nil || entries[1].Podcast.Duration != 1815*time.Second {
+		t.Errorf("entry Podcast (%+v) not as expected (a duration of 30:15)", entries[1].Podcast)
+	}
+}
+
+func Test_ItunesDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"3600", time.Hour, true},
+		{" 45:07 ", 45*time.Minute + 7*time.Second, true},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"00:00:01.5", 1500 * time.Millisecond, true},
+		{"1:2:3:4", 0, false},
+		{"an hour", 0, false},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"-Inf", 0, false},
+		{"1:NaN", 0, false},
+		{"1e400", 0, false},
+		{"1e300", 0, false},
+		{"9300000000", 0, false},
+		{"9000000000", 9000000000 * time.Second, true},
+	}
+	for _, test := range tests {
+		actual, ok := parseItunesDuration(test.value)
+		if actual != test.expected || ok != test.ok {
+			t.Errorf("parseItunesDuration(%q) = %v, %v, expected %v, %v", test.value, actual, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func Test_MediaDuration(t *testing.T) {
+	content := `<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/"><channel><item>
+<media:content url="http://example.com/a.mp4" duration="90.5"/>
+<media:content url="http://example.com/b.mp4" duration="Inf"/>
+<media:content url="http://example.com/c.mp4" duration="NaN"/>
+<media:content url="http://example.com/d.mp4" duration="1e300"/>
+<media:content url="http://example.com/e.mp4" duration="-1"/>
+</item></channel></rss>`
+	_, entries := parseFeed("Media duration", content, t)
+	if len(entries) != 1 || entries[0].Media == nil || len(entries[0].Media.Contents) != 5 {
+		t.Fatalf("entries (%+v) not as expected (one with 5 media:content)", entries)
+	}
+	for i, expected := range []time.Duration{90500 * time.Millisecond, 0, 0, 0, 0} {
+		if actual := entries[0].Media.Contents[i].Duration; actual != expected {
+			t.Errorf("duration of %s (%v) not as expected (%v)", entries[0].Media.Contents[i].URL, actual, expected)
+		}
+	}
+}
+
+func Test_MediaRss(t *testing.T) {
+	_, entries := parseFeed("Media RSS", mediaContent, t)
+	if len(entries) != 2 {
+		t.Fatalf("entry count (%d) not as expected (2)", len(entries))
+	}
+
+	video := entries[0]
+	cmpStr("entry Title", "Big Buck Bunny", video.Title, t)
+	cmpStr("entry Thumbnail", "http://example.com/bbb/large.jpg", video.Thumbnail, t)
+	if video.Media == nil {
+		t.Fatal("no media elements on the first entry")
+	}
+	expMedia := &Media{
+		MediaGroup: MediaGroup{
+			MediaDetails: MediaDetails{
+				Description: "A large rabbit &amp; three rodents",
+				Credits:     []MediaCredit{{Name: "Blender Foundation", Role: "producer", Scheme: "urn:ebu"}},
+			},
+		},
+		Groups: []MediaGroup{{
+			MediaDetails: MediaDetails{
+				Thumbnails: []MediaThumbnail{{URL: "http://example.com/bbb/small.jpg", Width: 120, Height: 90}},
+			},
+			Contents: []MediaContent{
+				{URL: "http://example.com/bbb/480.mp4", Type: "video/mp4", Medium: "video", FileSize: 64657027, Width: 854, Height: 480, Duration: 596 * time.Second, IsDefault: true},
+				{
+					MediaDetails: MediaDetails{
+						Thumbnails:  []MediaThumbnail{{URL: "http://example.com/bbb/large.jpg", Width: 1280, Height: 720}},
+						Description: "<b>HD</b>",
+					},
+					URL: "http://example.com/bbb/720.mp4", Type: "video/mp4", Medium: "video", Width: 1280, Height: 720, Duration: 596500 * time.Millisecond,
+				},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(expMedia, video.Media) {
+		t.Errorf("entry Media (%+v) not as expected (%+v)", *video.Media, *expMedia)
+	}
+
+	gallery := entries[1]
+	cmpStr("entry Title", "Gallery", gallery.Title, t)
+	cmpStr("entry Thumbnail", "http://example.com/gallery/2.jpg", gallery.Thumbnail, t)
+	if gallery.Media == nil || len(gallery.Media.Contents) != 3 {
+		t.Fatalf("entry Media (%+v) doesn't have the three contents expected", gallery.Media)
+	}
+	cmpStr("entry Media content", "http://example.com/gallery/3.png", gallery.Media.Contents[2].URL, t)
+}
+
+func Test_ThumbnailFallbacks(t *testing.T) {
+	tests := []struct {
+		name, item, expected string
+	}{
+		{"thumbnail", `<media:thumbnail url="http://example.com/t.jpg"/><enclosure url="http://example.com/e.jpg" type="image/jpeg"/>`, "http://example.com/t.jpg"},
+		{"enclosure", `<enclosure url="http://example.com/e.mp3" type="audio/mpeg"/><enclosure url="http://example.com/e.jpg" type="image/jpeg"/>`, "http://example.com/e.jpg"},
+		{"itunes", `<itunes:image href="http://example.com/i.jpg"/><description>&lt;img src="http://example.com/d.jpg"&gt;</description>`, "http://example.com/i.jpg"},
+		{"content", `<description>&lt;p&gt;&lt;img src="http://example.com/pixel.gif" width="1" height="1"&gt;&lt;IMG alt="" SRC="http://example.com/d.jpg?a=1&amp;amp;b=2"&gt;&lt;/p&gt;</description>`, "http://example.com/d.jpg?a=1&b=2"},
+		{"encoded", `<description>No images</description><content:encoded><![CDATA[<p>Text</p><img src="http://example.com/c.png" />]]></content:encoded>`, "http://example.com/c.png"},
+		{"tracking pixels only", `<description>&lt;img src="http://example.com/pixel.gif" width="1px" height="1px"/&gt;</description>`, ""},
+		{"none", `<description>Nothing</description>`, ""},
+	}
+	for _, test := range tests {
+		content := "<rss><channel><item>" + test.item + "</item></channel></rss>"
+		_, entries := parseFeed(test.name, content, t)
+		if len(entries) != 1 {
+			t.Errorf("%s: entry count (%d) not as expected (1)", test.name, len(entries))
+			continue
+		}
+		cmpStr(test.name+" entry Thumbnail", test.expected, entries[0].Thumbnail, t)
+	}
+}
+
+func Test_DublinCore(t *testing.T) {
+	content := `<rss xmlns:dc="http://purl.org/dc/elements/1.1/"><channel>
+<title>Dublin Core</title>
+<dc:creator>Channel Creator</dc:creator>
+<dc:date>2013-08-21T00:57:11Z</dc:date>
+<dc:subject>Programming</dc:subject>
+<dc:rights>Copyright 2013</dc:rights>
+<dc:language>en-gb</dc:language>
+<dc:publisher>Publisher</dc:publisher>
+<item>
+<title>Only Dublin Core</title>
+<dc:creator>Jo Bloggs</dc:creator>
+<dc:creator>Sam Smith</dc:creator>
+<dc:date>2013-08-20T10:00:00+10:00</dc:date>
+<dc:subject>Go</dc:subject>
+<dc:subject>XML</dc:subject>
+<dc:rights>CC BY</dc:rights>
+</item>
+<item>
+<dc:creator>Ignored</dc:creator>
+<dc:date>2001-01-01T00:00:00Z</dc:date>
+<dc:subject>Ignored</dc:subject>
+<title>RSS first</title>
+<author>author@example.com (The Author)</author>
+<pubDate>Wed, 21 Aug 2013 00:57:11 +0000</pubDate>
+<category>Category</category>
+</item>
+<item>
+<title>Publisher only</title>
+<dc:publisher>jo@example.com (Jo Bloggs)</dc:publisher>
+</item>
+</channel></rss>`
+
+	feed, entries := parseFeed("Dublin Core", content, t)
+	cmpStr("feed Author", "Channel Creator", feed.Author, t)
+	cmpTime("feed PublishDate", time.Date(2013, 8, 21, 0, 57, 11, 0, time.UTC), feed.PublishDate.UTC(), t)
+	cmpStr("feed Category", "Programming", feed.Category, t)
+	cmpStr("feed Copyright", "Copyright 2013", feed.Copyright, t)
+	cmpStr("feed Language", "en-gb", feed.Language, t)
+	if feed.DublinCore == nil || feed.DublinCore.Publisher != "Publisher" {
+		t.Errorf("feed DublinCore (%+v) doesn't have the publisher", feed.DublinCore)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("entry count (%d) not as expected (3)", len(entries))
+	}
+	first := entries[0]
+	cmpStr("entry Author", "Jo Bloggs", first.Author, t)
+	cmpPeople("entry Authors", []Person{{Name: "Jo Bloggs"}, {Name: "Sam Smith"}}, first.Authors, t)
+	cmpTime("entry UpdatedDate", time.Date(2013, 8, 20, 0, 0, 0, 0, time.UTC), first.UpdatedDate.UTC(), t)
+	cmpCategories("entry Categories", []Category{{Term: "Go"}, {Term: "XML"}}, first.Categories, t)
+	cmpStr("entry Copyright", "CC BY", first.Copyright, t)
+
+	second := entries[1]
+	cmpStr("entry Author", "The Author", second.Author, t)
+	cmpPeople("entry Authors", []Person{{Name: "The Author", Email: "author@example.com"}}, second.Authors, t)
+	cmpTime("entry UpdatedDate", time.Date(2013, 8, 21, 0, 57, 11, 0, time.UTC), second.UpdatedDate.UTC(), t)
+	cmpCategories("entry Categories", []Category{{Term: "Category"}}, second.Categories, t)
+
+	third := entries[2]
+	cmpPeople("entry Authors", []Person{{Name: "Jo Bloggs", Email: "jo@example.com"}}, third.Authors, t)
+}
+
+// geoPoint is what the test's extension handler attaches for a georss:point
+type geoPoint struct {
+	Lat, Long string
+}
+
+func Test_Extensions(t *testing.T) {
+	const nsGeoRSS = "http://www.georss.org/georss"
+	const nsExample = "http://example.com/ns"
+	content := `<rss xmlns:slash="http://purl.org/rss/1.0/modules/slash/" xmlns:georss="http://www.georss.org/georss" xmlns:ex="http://example.com/ns"><channel>
+<title>Extensions</title>
+<ex:owner id="42"><ex:name>Jo Bloggs</ex:name><ex:email>jo@example.com</ex:email><ex:other>skipped</ex:other></ex:owner>
+<ex:title>Not the title</ex:title>
+<item>
+<title>Built in</title>
+<link>http://example.com/1</link>
+<slash:comments>12</slash:comments>
+<georss:point>45.256 -71.92</georss:point>
+<ex:note>A <b>bold</b>.</ex:note>
+<ex:tags draft="">go <ex:tag>xml</ex:tag> rss</ex:tags>
+<guid>1</guid>
+</item>
+</channel></rss>`
+
+	parser := NewParser("Extensions", content)
+	parser.RegisterFeedExtension(nsExample, "owner", func(e *Element, feed *Feed) {
+		owner := map[string]string{"id": e.Attr("id")}
+		e.Children(func(child *Element) {
+			if child.Name != "other" {
+				owner[child.Name] = child.Text()
+			}
+		})
+		feed.Extensions.Set(e.Namespace, e.Name, owner)
+	})
+	parser.RegisterEntryExtension(nsSlash, "comments", func(e *Element, entry *Entry) {
+		if n, err := strconv.Atoi(e.Text()); err == nil {
+			entry.Extensions.Set(e.Namespace, e.Name, n)
+		}
+	})
+	parser.RegisterEntryExtension(nsGeoRSS, "point", func(e *Element, entry *Entry) {
+		if fields := strings.Fields(e.Text()); len(fields) == 2 {
+			entry.Extensions.Set(e.Namespace, e.Name, geoPoint{fields[0], fields[1]})
+		}
+	})
+	parser.RegisterEntryExtension(nsExample, "note", func(e *Element, entry *Entry) {
+		entry.Extensions.Set(e.Namespace, e.Name, e.Html())
+	})
+	parser.RegisterEntryExtension(nsExample, "tags", func(e *Element, entry *Entry) {
+		var tags []string
+		if _, draft := e.LookupAttr("draft"); draft {
+			tags = append(tags, "draft")
+		}
+		e.Content(func(text string) {
+			tags = append(tags, strings.Fields(text)...)
+		}, func(child *Element) {
+			tags = append(tags, child.Text())
+		})
+		entry.Extensions.Set(e.Namespace, e.Name, tags)
+	})
+	// handlers can replace the built-in ones, or turn them off
+	parser.RegisterEntryExtension("", "title", func(e *Element, entry *Entry) {
+		entry.Title = strings.ToUpper(e.Text())
+	})
+	parser.RegisterEntryExtension("", "link", nil)
+
+	feed, entries, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmpStr("feed Title", "Extensions", feed.Title, t)
+	owner := map[string]string{"id": "42", "name": "Jo Bloggs", "email": "jo@example.com"}
+	if actual := feed.Extensions.Get(nsExample, "owner"); !reflect.DeepEqual(actual, owner) {
+		t.Errorf("feed owner extension (%v) not as expected (%v)", actual, owner)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	entry := entries[0]
+	cmpStr("entry Title", "BUILT IN", entry.Title, t)
+	cmpStr("entry Link", "", entry.Link, t)
+	cmpStr("entry Guid", "1", entry.Guid, t)
+	if comments, ok := entry.Extensions.Get(nsSlash, "comments").(int); !ok || comments != 12 {
+		t.Errorf("entry comments extension (%v) not as expected (12)", entry.Extensions.Get(nsSlash, "comments"))
+	}
+	if point, ok := entry.Extensions.Get(nsGeoRSS, "point").(geoPoint); !ok || point != (geoPoint{"45.256", "-71.92"}) {
+		t.Errorf("entry point extension (%v) not as expected", entry.Extensions.Get(nsGeoRSS, "point"))
+	}
+	if note := entry.Extensions.Get(nsExample, "note"); note != "A <b>bold</b>." {
+		t.Errorf("entry note extension (%q) not as expected", note)
+	}
+	tags := []string{"draft", "go", "xml", "rss"}
+	if actual := entry.Extensions.Get(nsExample, "tags"); !reflect.DeepEqual(actual, tags) {
+		t.Errorf("entry tags extension (%v) not as expected (%v)", actual, tags)
+	}
+
+	// the handlers registered on the parser are its own
+	feed, entries = parseFeed("Extensions", content, t)
+	cmpStr("entry Title", "Built in", entries[0].Title, t)
+	cmpStr("entry Link", "http://example.com/1", entries[0].Link, t)
+	if feed.Extensions != nil || entries[0].Extensions != nil {
+		t.Errorf("extensions (%v, %v) from handlers not registered", feed.Extensions, entries[0].Extensions)
+	}
+}
+
+func Test_RegisterExtension(t *testing.T) {
+	content := `<feed xmlns="http://purl.org/atom/ns#" xmlns:ex="http://example.com/ns">
+<title>Atom 0.3</title>
+<ex:rating>5</ex:rating>
+<entry><title>Entry</title><tagline>Subtitle</tagline></entry>
+</feed>`
+
+	// handlers registered for an older namespace also handle the one it's
+	// read as
+	RegisterFeedExtension("http://example.com/ns", "rating", func(e *Element, feed *Feed) {
+		feed.Extensions.Set(e.Namespace, e.Name, e.Text())
+	})
+	RegisterEntryExtension(nsAtom03, "tagline", handleEntrySubtitle)
+	defer RegisterFeedExtension("http://example.com/ns", "rating", nil)
+	defer RegisterEntryExtension(nsAtom03, "tagline", nil)
+
+	feed, entries := parseFeed("Register", content, t)
+	if rating := feed.Extensions.Get("http://example.com/ns", "rating"); rating != "5" {
+		t.Errorf("feed rating extension (%v) not as expected (5)", rating)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	cmpStr("entry Subtitle", "Subtitle", entries[0].Subtitle, t)
+}
+
+func Test_RelativeURLs(t *testing.T) {
+	content := `<feed xmlns="http://www.w3.org/2005/Atom" xml:base="http://example.com/blog/">
+<title>Relative</title>
+<link href="/"/>
+<logo>images/logo.png</logo>
+<icon>http://cdn.example.com/icon.png</icon>
+<entry xml:base="2013/08/">
+<title>First</title>
+<link href="first.html"/>
+<link rel="enclosure" href="first.mp3" type="audio/mpeg" length="100"/>
+<author><name>Jo</name><uri xml:base="http://people.example.com/">jo</uri></author>
+<content type="html" xml:base="/static/">&lt;p&gt;&lt;img src="a.png"&gt; &lt;a href='../b?x=1&amp;amp;y=2'&gt;b&lt;/a&gt; &lt;a href="#top"&gt;top&lt;/a&gt; &lt;a href=mailto:jo@example.com&gt;mail&lt;/a&gt;&lt;/p&gt;</content>
+</entry>
+<entry>
+<title>Second</title>
+<link href="second.html"/>
+</entry>
+</feed>`
+
+	feed, entries := parseFeed("Relative", content, t)
+	cmpStr("feed Link", "http://example.com/", feed.Link, t)
+	cmpStr("feed Logo", "http://example.com/blog/images/logo.png", feed.Logo, t)
+	cmpStr("feed Icon", "http://cdn.example.com/icon.png", feed.Icon, t)
+	if len(entries) != 2 {
+		t.Fatalf("entry count (%d) not as expected (2)", len(entries))
+	}
+	first := entries[0]
+	cmpStr("entry Link", "http://example.com/blog/2013/08/first.html", first.Link, t)
+	cmpStr("entry Url", "http://example.com/blog/2013/08/first.mp3", first.Url, t)
+	cmpPeople("entry Authors", []Person{{Name: "Jo", URI: "http://people.example.com/jo"}}, first.Authors, t)
+	cmpStr("entry Content", `<p><img src="http://example.com/static/a.png"> <a href='http://example.com/b?x=1&amp;y=2'>b</a> <a href="#top">top</a> <a href=mailto:jo@example.com>mail</a></p>`, first.Content, t)
+	cmpStr("entry Thumbnail", "http://example.com/static/a.png", first.Thumbnail, t)
+	cmpStr("second entry Link", "http://example.com/blog/second.html", entries[1].Link, t)
+
+	// without an xml:base, URLs are relative to the feed's own
+	content = `<rss><channel>
+<title>Relative</title>
+<link>/</link>
+<image><url>logo.png</url><title>Logo</title><link>/</link></image>
+<item>
+<title>Item</title>
+<link>posts/1</link>
+<comments>posts/1#comments</comments>
+<enclosure url="/audio/1.mp3" type="audio/mpeg" length="100"/>
+<description>&lt;img src="/images/1.png"&gt;</description>
+</item>
+</channel></rss>`
+
+	feed, entries = parseFeed("http://example.com/feeds/rss.xml", content, t)
+	cmpStr("feed Link", "http://example.com/", feed.Link, t)
+	cmpImage("feed Image", &Image{Url: "http://example.com/feeds/logo.png", Title: "Logo", Link: "http://example.com/"}, feed.Image, t)
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	cmpStr("entry Link", "http://example.com/feeds/posts/1", entries[0].Link, t)
+	cmpStr("entry Comments", "http://example.com/feeds/posts/1#comments", entries[0].Comments, t)
+	cmpEnclosures("entry Enclosures", []Enclosure{{URL: "http://example.com/audio/1.mp3", Type: "audio/mpeg", Length: 100}}, entries[0].Enclosures, t)
+	cmpStr("entry Summary", `<img src="http://example.com/images/1.png">`, entries[0].Summary, t)
+
+	// and with neither, they're left as they are
+	feed, entries = parseFeed("Relative", content, t)
+	cmpStr("feed Link", "/", feed.Link, t)
+	cmpStr("entry Link", "posts/1", entries[0].Link, t)
+}
+
+func Test_ResolveHtml(t *testing.T) {
+	base := "http://example.com/a/"
+	tests := []struct{ content, expected string }{
+		{`no links`, `no links`},
+		{`<img src="b.png">`, `<img src="http://example.com/a/b.png">`},
+		{`<IMG SRC='b.png' ALT="b">`, `<IMG SRC='http://example.com/a/b.png' ALT="b">`},
+		{`<a href = b.html>b</a>`, `<a href = http://example.com/a/b.html>b</a>`},
+		{`<a title="src=x" href="/b?x=1&amp;y=2">`, `<a title="src=x" href="http://example.com/b?x=1&amp;y=2">`},
+		{`<a href="#b">b</a><a href="http://example.org/">c</a>`, `<a href="#b">b</a><a href="http://example.org/">c</a>`},
+		{`<p>src="b.png"</p><img data-src="b.png">`, `<p>src="b.png"</p><img data-src="b.png">`},
+		{`<img src="b.png`, `<img src="b.png`},
+		{`<img src`, `<img src`},
+	}
+	for _, test := range tests {
+		cmpStr(test.content, test.expected, resolveHtml(base, test.content), t)
+	}
+	cmpStr("no base", `<img src="b.png">`, resolveHtml("", `<img src="b.png">`), t)
+}
+
+func Test_Sanitize(t *testing.T) {
+	sanitizer := DefaultSanitizer()
+	tests := []struct{ content, expected string }{
+		{`plain text`, `plain text`},
+		{`<p>Hello <b>world</b></p>`, `<p>Hello <b>world</b></p>`},
+		{`<P CLASS="intro" Title="t">Hi</P>`, `<p title="t">Hi</p>`},
+		{`<p>Before<script>alert("x") && y < z</script>after</p>`, `<p>Beforeafter</p>`},
+		{`<style>p { color: red }</style><p>Text</p>`, `<p>Text</p>`},
+		{`<script>if (a<b && c<d) { x() }</script><p>kept</p>`, `<p>kept</p>`},
+		{`<SCRIPT>document.write("<p>not kept</p></scripts>")</SCRIPT >after`, `after`},
+		{`<textarea><b>not bold</b></textarea><b>bold</b>`, `<b>bold</b>`},
+		{`<img src="x.png" onerror="alert(1)" alt="x">`, `<img src="x.png" alt="x">`},
+		{`<a href="javascript:alert(1)">link</a>`, `<a>link</a>`},
+		{`<a href=" JaVa&#9;Script:alert(1)">link</a>`, `<a>link</a>`},
+		{`<a href="http://example.com/?a=1&amp;b=2">link</a>`, `<a href="http://example.com/?a=1&amp;b=2">link</a>`},
+		{`<a href="/relative">link</a><a href="mailto:jo@example.com">mail</a>`, `<a href="/relative">link</a><a href="mailto:jo@example.com">mail</a>`},
+		{`<font color="red">red</font> <o:p>word</o:p>`, `red word`},
+		{`<iframe src="http://example.com/"><p>fallback</p></iframe>done`, `done`},
+		{`<p>unclosed <em>tags`, `<p>unclosed <em>tags</em></p>`},
+		{`<p>line<br>break<br/>again</p>`, `<p>line<br>break<br>again</p>`},
+		{`text &amp; &lt;entities&gt;`, `text &amp; &lt;entities&gt;`},
+		{`<img src="http://tracker.example.com/p.gif" width="1" height="1"><img src="a.png" width="100">`, `<img src="a.png" width="100">`},
+		{`<img src=p.gif width=1 height=1/>`, ``},
+		{`</div><p>stray</p>`, `<p>stray</p>`},
+	}
+	for _, test := range tests {
+		cmpStr(test.content, test.expected, sanitizer.Sanitize(test.content), t)
+	}
+
+	// what's allowed can be changed
+	sanitizer.Elements["iframe"] = []string{"src"}
+	delete(sanitizer.DropContent, "iframe")
+	delete(sanitizer.Elements, "b")
+	sanitizer.KeepTrackingPixels = true
+	cmpStr("iframe", `<iframe src="http://example.com/"></iframe>`, sanitizer.Sanitize(`<iframe src="http://example.com/" onload="x()"></iframe>`), t)
+	cmpStr("b", `bold`, sanitizer.Sanitize(`<b>bold</b>`), t)
+	cmpStr("tracking pixel", `<img src="p.gif" width="1" height="1">`, sanitizer.Sanitize(`<img src="p.gif" width="1" height="1">`), t)
+	cmpStr("default", `bold`, DefaultSanitizer().Sanitize(`<iframe>x</iframe>bold`), t)
+}
+
+func Test_TrackingPixel(t *testing.T) {
+	tests := []struct {
+		size     string
+		tracking bool
+	}{
+		{`"1"`, true},
+		{`"0"`, true},
+		{`"1px"`, true},
+		{`" 1 "`, true},
+		{`1/`, true},
+		{`"2"`, false},
+		{`"100%"`, false},
+		{`""`, false},
+	}
+	sanitizer := DefaultSanitizer()
+	for _, test := range tests {
+		content := `<img src="http://example.com/a.png" width=` + test.size + `>`
+		if isTrackingPixelSize(strings.Trim(test.size, `"`)) != test.tracking {
+			t.Errorf("isTrackingPixelSize(%s) not as expected (%v)", test.size, test.tracking)
+		}
+		// the sanitizer and thumbnails agree
+		if dropped := !strings.Contains(sanitizer.Sanitize(content), "<img"); dropped != test.tracking {
+			t.Errorf("sanitizing %s dropped it: %v, expected %v", content, dropped, test.tracking)
+		}
+		if passed := firstImage(content) == ""; passed != test.tracking {
+			t.Errorf("firstImage of %s passed over it: %v, expected %v", content, passed, test.tracking)
+		}
+	}
+}
+
+func Test_SanitizeEntry(t *testing.T) {
+	entry := &Entry{
+		Summary: `<p onclick="x()">Summary</p>`,
+		Encoded: `<script>x()</script><p>Encoded</p>`,
+		Content: `Content`,
+	}
+	original := EntryHtml{Summary: entry.Summary, Encoded: entry.Encoded, Content: entry.Content}
+
+	sanitizer := DefaultSanitizer()
+	sanitizer.SanitizeEntry(entry)
+	cmpStr("Summary", `<p>Summary</p>`, entry.Summary, t)
+	cmpStr("Encoded", `<p>Encoded</p>`, entry.Encoded, t)
+	cmpStr("Content", `Content`, entry.Content, t)
+	if entry.Original == nil || *entry.Original != original {
+		t.Errorf("Original (%+v) not as expected (%+v)", entry.Original, original)
+	}
+
+	// sanitizing again keeps the html from before the first time
+	sanitizer.SanitizeEntry(entry)
+	if entry.Original == nil || *entry.Original != original {
+		t.Errorf("Original (%+v) not as expected (%+v)", entry.Original, original)
+	}
+}
+
+func Test_PlainText(t *testing.T) {
+	tests := []struct{ content, expected string }{
+		{``, ``},
+		{"  plain\n\ttext  ", `plain text`},
+		{`<p>Hello <b>world</b></p><p>Again</p>`, `Hello world Again`},
+		{`Fish &amp; chips&nbsp;&nbsp;&#8212; &lt;b&gt;`, "Fish & chips — <b>"},
+		{`line<br>break<br/>again<hr/>end`, `line break again end`},
+		{`<p>Before<script>if (a < b) { x() }</script><style>p { }</style>after</p>`, `Beforeafter`},
+		{`<script>if (a<b) { x() }</script><p>shown</p>`, `shown`},
+		{`<title>A <b>title</title>text`, `text`},
+		{`<ul><li>one</li><li>two</li></ul>`, `one two`},
+		{`<span>run</span><em>together</em>`, `runtogether`},
+		{`<img src="a.png" alt="a">caption`, `caption`},
+		{`<![CDATA[cdata]]> text`, `cdata text`},
+		{`unclosed <b>tag`, `unclosed tag`},
+	}
+	for _, test := range tests {
+		cmpStr(test.content, test.expected, PlainText(test.content), t)
+	}
+}
+
+func Test_Excerpt(t *testing.T) {
+	tests := []struct {
+		text     string
+		length   int
+		expected string
+	}{
+		{`short`, 10, `short`},
+		{`exactly ten`, 11, `exactly ten`},
+		{`the quick brown fox jumps`, 16, `the quick brown…`},
+		{`the quick brown fox jumps`, 15, `the quick…`},
+		{`the quick, brown fox`, 12, `the quick…`},
+		{`unbreakableword`, 8, `unbreak…`},
+		{`naïve café crème`, 12, `naïve café…`},
+		{`anything`, 1, ``},
+		{`anything`, 0, ``},
+	}
+	for _, test := range tests {
+		cmpStr(test.text, test.expected, Excerpt(test.text, test.length), t)
+	}
+}
+
+func Test_EntryText(t *testing.T) {
+	entry := &Entry{
+		Summary: `<p>A short summary.</p>`,
+		Content: `<p>The whole article, which goes on <em>and on</em>.</p>`,
+	}
+	cmpStr("Text", "The whole article, which goes on and on.", entry.Text(), t)
+	cmpStr("Excerpt", "A short…", entry.Excerpt(10), t)
+
+	// without a summary, the excerpt comes from the text
+	entry = &Entry{Encoded: `<p>Encoded &amp; excerpted</p>`}
+	cmpStr("Text", "Encoded & excerpted", entry.Text(), t)
+	cmpStr("Excerpt", "Encoded &…", entry.Excerpt(10), t)
+
+	// and with no content, the text is the summary
+	entry = &Entry{Summary: `Only a summary`}
+	cmpStr("Text", "Only a summary", entry.Text(), t)
+	cmpStr("Excerpt", "Only a summary", entry.Excerpt(100), t)
+}
+
+func Test_ParseDate(t *testing.T) {
+	tests := []struct {
+		date     string
+		expected string // in RFC 3339
+		layout   string
+	}{
+		{`Tue, 23 Apr 2013 17:08:09 GMT`, `2013-04-23T17:08:09Z`, time.RFC1123},
+		{`Tue, 23 Apr 2013 17:08:09 PDT`, `2013-04-23T17:08:09-07:00`, time.RFC1123},
+		{`Tue, 23 Apr 2013 17:08:09 EST`, `2013-04-23T17:08:09-05:00`, time.RFC1123},
+		{`Tue, 23 Apr 2013 17:08:09 AEST`, `2013-04-23T17:08:09+10:00`, time.RFC1123},
+		{`Tue, 23 Apr 2013 17:08:09 CET`, `2013-04-23T17:08:09+01:00`, time.RFC1123},
+		{`Tue, 23 Apr 2013 17:08:09 IST`, `2013-04-23T17:08:09+05:30`, time.RFC1123},
+		{`Tue, 23 Apr 2013 17:08:09 XYZ`, `2013-04-23T17:08:09Z`, time.RFC1123},
+		{`Tue, 23 Apr 2013 17:08:09 +1000`, `2013-04-23T17:08:09+10:00`, time.RFC1123Z},
+		{`Tue, 23 Apr 2013 17:08:09 PDT -0400`, `2013-04-23T17:08:09-04:00`, `Mon, 02 Jan 2006 15:04:05 MST -0700`},
+		{`Tue, 23 Apr 2013 17:08:09 --0400`, `2013-04-23T17:08:09-04:00`, `Mon, 02 Jan 2006 15:04:05 --0700`},
+		{`Tue, 23 Apr 13 17:08 EDT`, `2013-04-23T17:08:00-04:00`, `Mon, 02 Jan 06 15:04 MST`},
+		{`23 Apr 2013 17:08:09 -700`, `2013-04-23T17:08:09-07:00`, `02 Jan 2006 15:04:05 -0700`},
+		{`Tue, 3 Apr 2013 7:8:9 UT`, `2013-04-03T07:08:09Z`, `Mon, 2 Jan 2006 15:4:5 MST`},
+		{`2013-04-23T17:08:09Z`, `2013-04-23T17:08:09Z`, time.RFC3339},
+		{`2013-04-23T17:08:09+02:00`, `2013-04-23T17:08:09+02:00`, time.RFC3339},
+		{`2013-04-23T17:08:09.123-02:00`, `2013-04-23T17:08:09.123-02:00`, `2006-01-02T15:04:05.000Z07:00`},
+		{`2013-04-23 17:08:09`, `2013-04-23T17:08:09Z`, `2006-01-02 15:04:05`},
+		{`2013-04-23`, `2013-04-23T00:00:00Z`, `2006-01-02`},
+		{`04/23/2013`, `2013-04-23T00:00:00Z`, `01/02/2006`},
+		{`23/04/2013`, `2013-04-23T00:00:00Z`, `02/01/2006`},
+		{`03/04/2013`, `2013-03-04T00:00:00Z`, `01/02/2006`},
+		{`03.04.2013`, `2013-04-03T00:00:00Z`, `02.01.2006`},
+		{`13-4-23 17:08`, `2013-04-23T17:08:00Z`, `06-1-02 15:04`},
+		{`April 23, 2013, 5:08 p.m.`, `2013-04-23T17:08:00Z`, `January 02, 2006, 3:04 pm`},
+		{`Apr 23 2013 12:08:09AM`, `2013-04-23T00:08:09Z`, `Jan 02 2006 03:04:05PM`},
+		{`Updated April 23, 2013`, `2013-04-23T00:00:00Z`, `Updated January 02, 2006`},
+		{`Tue Apr 23 17:08:09 PST 2013`, `2013-04-23T17:08:09-08:00`, `Mon Jan 02 15:04:05 MST 2006`},
+		{`Tue, 10 Jun 2003 04:00:00 +0000 (UTC)`, `2003-06-10T04:00:00Z`, time.RFC1123Z},
+		{`Tue, 10 Jun 2003 04:00:00 EDT (Eastern Daylight Time)`, `2003-06-10T04:00:00-04:00`, time.RFC1123},
+	}
+	for _, test := range tests {
+		expected, _ := time.Parse(time.RFC3339, test.expected)
+		actual, layout, err := ParseDate(test.date)
+		if err != nil {
+			t.Errorf("Error parsing %s: %v", test.date, err)
+			continue
+		}
+		if !actual.Equal(expected) {
+			t.Errorf("Error with %s. Expected %v, received %v.", test.date, expected, actual)
+		}
+		_, offset := actual.Zone()
+		if _, expectedOffset := expected.Zone(); offset != expectedOffset {
+			t.Errorf("Error with %s's zone. Expected %d, received %d.", test.date, expectedOffset, offset)
+		}
+		cmpStr(test.date+" layout", test.layout, layout, t)
+	}
+
+	for _, date := range []string{``, `  `, `soon`, `Tue, 23 Foo 2013`, `2013-02-30`,
+		`2013-04-23 25:00`, `Tue, 23 Apr 17:08:09 GMT`, `13:00 PM 23 Apr 2013`, `2013-04-23 17:08 +1500`} {
+		if actual, _, err := ParseDate(date); err == nil {
+			t.Errorf("Expected an error parsing %q, received %v", date, actual)
+		}
+	}
+}
+
+// ParseDate reads what trying each of the old layouts in turn did, where
+// that was right
+func Test_ParseDateLoop(t *testing.T) {
+	skip := map[string]string{
+		"Mon, 2 Jan 15:04:05 MST":          "was read as the year 0",
+		"6-1-2 15:04":                      "was read as the year 0",
+		"6/1/2 15:04":                      "was read as the year 0",
+		"Mon,02 January 2006 14:04:05 MST": "has a literal hour",
+		"January 2, 2006, 3:04 p.m.":       "was read as am",
+		"Mon, Jan 2 2006 15:04:05 -700":    "was read as UTC",
+		"Mon, 02 Jan 2006 15:04:05 -700":   "formats -700 literally",
+		"Mon, 02 Jan 2006 15:04:05 --0700": "formats --0700 as -+0000",
+	}
+	when := time.Date(2013, time.April, 23, 17, 8, 9, 0, time.UTC)
+	for _, layout := range dateFormats {
+		if _, ok := skip[layout]; ok {
+			continue
+		}
+		date := when.Format(layout)
+		expected, err := parseDateLoop(date)
+		if err != nil {
+			t.Errorf("Error parsing %s the old way: %v", date, err)
+			continue
+		}
+		actual, err := parseDate(date)
+		if err != nil {
+			t.Errorf("Error parsing %s (%s): %v", date, layout, err)
+		} else if !actual.Equal(expected) {
+			t.Errorf("Error with %s (%s). Expected %v, received %v.", date, layout, expected, actual)
+		}
+	}
+}
+
+func Test_EntryDates(t *testing.T) {
+	content := `<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/"><channel>
+<title>Dates</title>
+<item><title>Published</title><pubDate>Tue, 23 Apr 2013 17:08:09 PDT</pubDate></item>
+<item><title>Dublin Core</title><dc:date>2013-04-23T17:08:09Z</dc:date></item>
+<item><title>Undated</title></item>
+<item><title>Unparseable</title><pubDate>sometime last week</pubDate></item>
+<item><title>Epoch</title><pubDate>Thu, 01 Jan 1970 00:00:00 GMT</pubDate></item>
+<item><title>Future</title><pubDate>Sat, 01 Jan 2050 00:00:00 GMT</pubDate></item>
+</channel></rss>`
+	fetched := time.Date(2013, time.May, 1, 12, 0, 0, 0, time.UTC)
+	parser := NewParser("Dates", content)
+	parser.SetFetchTime(fetched)
+	_, entries, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("entry count (%d) not as expected (6)", len(entries))
+	}
+
+	published := time.Date(2013, time.April, 24, 0, 8, 9, 0, time.UTC)
+	expected := []struct {
+		date   time.Time
+		source string
+	}{
+		{published, DatePublished},
+		{published.Add(-7 * time.Hour), DatePublished},
+		{fetched, DateFirstSeen},
+		{fetched, DateFirstSeen},
+		{fetched, DateFirstSeen},
+		{fetched, DateFirstSeen},
+	}
+	for i, entry := range entries {
+		if !entry.PublishDate.Equal(expected[i].date) {
+			t.Errorf("Error with %s's PublishDate. Expected %v, received %v.", entry.Title, expected[i].date, entry.PublishDate)
+		}
+		if !entry.UpdatedDate.Equal(entry.PublishDate) {
+			t.Errorf("Error with %s's UpdatedDate. Expected %v, received %v.", entry.Title, entry.PublishDate, entry.UpdatedDate)
+		}
+		cmpStr(entry.Title+" DateSource", expected[i].source, entry.DateSource, t)
+		cmpTime(entry.Title+" FirstSeen", fetched, entry.FirstSeen, t)
+	}
+}
+
+func Test_AtomEntryDates(t *testing.T) {
+	content := `<feed xmlns="http://www.w3.org/2005/Atom">
+<title>Dates</title>
+<entry><title>Both</title><published>2013-04-20T10:00:00Z</published><updated>2013-04-23T10:00:00Z</updated></entry>
+<entry><title>Updated</title><updated>2013-04-23T10:00:00Z</updated></entry>
+<entry><title>Bad update</title><published>2013-04-20T10:00:00Z</published><updated>0001-01-01T00:00:00Z</updated></entry>
+</feed>`
+	fetched := time.Date(2013, time.May, 1, 12, 0, 0, 0, time.UTC)
+	parser := NewParser("Atom dates", content)
+	parser.SetFetchTime(fetched)
+	_, entries, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("entry count (%d) not as expected (3)", len(entries))
+	}
+
+	published := time.Date(2013, time.April, 20, 10, 0, 0, 0, time.UTC)
+	updated := time.Date(2013, time.April, 23, 10, 0, 0, 0, time.UTC)
+	cmpTime("Both PublishDate", published, entries[0].PublishDate, t)
+	cmpTime("Both UpdatedDate", updated, entries[0].UpdatedDate, t)
+	cmpStr("Both DateSource", DatePublished, entries[0].DateSource, t)
+	cmpTime("Updated PublishDate", updated, entries[1].PublishDate, t)
+	cmpTime("Updated UpdatedDate", updated, entries[1].UpdatedDate, t)
+	cmpStr("Updated DateSource", DateUpdated, entries[1].DateSource, t)
+	cmpTime("Bad update PublishDate", published, entries[2].PublishDate, t)
+	cmpTime("Bad update UpdatedDate", published, entries[2].UpdatedDate, t)
+	cmpStr("Bad update DateSource", DatePublished, entries[2].DateSource, t)
+
+	// JSON Feeds are checked the same way
+	json := `{"version": "https://jsonfeed.org/version/1.1", "title": "Dates", "items": [
+{"id": "1", "date_published": "1970-01-01T00:00:00Z"},
+{"id": "2", "date_published": "2013-04-20T10:00:00Z"}]}`
+	jsonParser := NewJsonFeedParser("JSON dates", strings.NewReader(json))
+	jsonParser.SetFetchTime(fetched)
+	if _, entries, err = jsonParser.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	cmpTime("JSON epoch PublishDate", fetched, entries[0].PublishDate, t)
+	cmpStr("JSON epoch DateSource", DateFirstSeen, entries[0].DateSource, t)
+	cmpTime("JSON PublishDate", published, entries[1].PublishDate, t)
+	cmpTime("JSON UpdatedDate", published, entries[1].UpdatedDate, t)
+	cmpStr("JSON DateSource", DatePublished, entries[1].DateSource, t)
+}
+
+func Test_DateWarnings(t *testing.T) {
+	content := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<updated>yesterday</updated>
+<entry><title>Bad</title><published>sometime last week</published><updated>never</updated><dc:date>someday</dc:date></entry>
+</feed>`
+	parser := NewParser("Date warnings", content)
+	parser.SetLenient(true)
+	_, _, err := parser.Parse()
+	warnings, ok := err.(LexErrorList)
+	if !ok || len(warnings) != 4 {
+		t.Fatalf("warnings (%v) not as expected (4)", err)
+	}
+	cmpStr("feed warning", "Could not parse date: yesterday", warnings[0].Msg, t)
+	cmpStr("published warning", "Could not parse date: sometime last week", warnings[1].Msg, t)
+	cmpStr("updated warning", "Could not parse date: never", warnings[2].Msg, t)
+	cmpStr("dc:date warning", "Could not parse date: someday", warnings[3].Msg, t)
+}
+
+func Test_ParserFromReader(t *testing.T) {
+	expF, expEs := parseFeed("Sutter's Mill", suttersMillContent, t)
+
+	parser := NewParserFromReader("Sutter's Mill", iotest.OneByteReader(strings.NewReader(suttersMillContent)))
+	actF, actEs, err := parser.Parse()
+	if err != nil {
+		t.Error(err)
+	}
+
+	cmpStr("feed Title", expF.Title, actF.Title, t)
+	cmpStr("feed Link", expF.Link, actF.Link, t)
+	if len(expEs) != len(actEs) {
+		t.Fatalf("Expected %d entries from reader, received %d", len(expEs), len(actEs))
+	}
+	for i, expected := range expEs {
+		cmpStr("entry Title", expected.Title, actEs[i].Title, t)
+		cmpStr("entry Encoded", expected.Encoded, actEs[i].Encoded, t)
+	}
+}
+
+func Test_ParseReturnsLexError(t *testing.T) {
+	content := "<rss>\n<channel>\n<title>t</title>\n<item><title>first</title></item>\n<item><title><![CDATA[never closed"
+	feed, entries, err := NewParser("broken", content).Parse()
+
+	lexErr, ok := err.(*LexError)
+	if !ok {
+		t.Fatalf("Expected a *LexError, received %v", err)
+	}
+	if lexErr.Name != "broken" || lexErr.Line != 5 {
+		t.Errorf("Error position (%s:%d) not as expected (broken:5)", lexErr.Name, lexErr.Line)
+	}
+	cmpStr("feed Title", "t", feed.Title, t)
+	if len(entries) == 0 {
+		t.Fatal("Expected the entries before the error to be returned")
+	}
+	cmpStr("entry Title", "first", entries[0].Title, t)
+}
+
+func Test_LenientParse(t *testing.T) {
+	content := `<rss><channel><title>Lenient</title>
+<item><title>AT&T < Verizon</title><description>Hello<br>world</description></item>
+<item><title =broken>oops</title></item>
+<item><title>Last</title><description><![CDATA[unclosed</description></item>
+</channel></rss>`
+
+	parser := NewParser("lenient", content)
+	parser.SetLenient(true)
+	feed, entries, err := parser.Parse()
+
+	warnings, ok := err.(LexErrorList)
+	if !ok {
+		t.Fatalf("Expected a LexErrorList, received %v", err)
+	}
+	if len(warnings) != 4 {
+		t.Errorf("Expected 4 warnings, received %d: %v", len(warnings), warnings)
+	}
+	cmpStr("feed Title", "Lenient", feed.Title, t)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, received %d", len(entries))
+	}
+	cmpStr("entry Title", "AT&T < Verizon", entries[0].Title, t)
+	cmpStr("entry Summary", "Hello<br>world", entries[0].Summary, t)
+	cmpStr("entry Title", "Last", entries[2].Title, t)
+	cmpStr("entry Summary", "unclosed", entries[2].Summary, t)
+}
+
+func Test_EntitiesInText(t *testing.T) {
+	var content = `<rss><channel>
+<title>Tom &amp; Jerry&#8217;s &hellip; &#x263A;</title>
+<link>http://example.com/?a=1&amp;b=2&c=3</link>
+<copyright>&copy; 2013 &#169; &ampx; &bogus;</copyright>
+<item>
+<title>It&#146;s &quot;quoted&quot;&nbsp;here</title>
+<link>http://example.com/1?x=1&amp;y=2</link>
+<description>&lt;p&gt;Escaped &amp;amp; html&lt;/p&gt;</description>
+</item>
+</channel></rss>`
+
+	f := new(Feed)
+	f.Title = "Tom & Jerry’s … ☺"
+	f.Link = "http://example.com/?a=1&b=2&c=3"
+	f.Copyright = "© 2013 © &ampx; &bogus;"
+
+	e := new(Entry)
+	e.Title = "It’s \"quoted\"\u00a0here"
+	e.Link = "http://example.com/1?x=1&y=2"
+	e.Summary = "<p>Escaped &amp; html</p>"
+
+	testContent("Entities", content, f, []*Entry{e}, t)
+}
+
+func Test_NamespacedElements(t *testing.T) {
+	var content = `<rss xmlns:m="http://search.yahoo.com/mrss/" xmlns:c="http://purl.org/rss/1.0/modules/content/" xmlns:content="http://example.com/not-content/">
+<channel>
+<title>Namespaces</title>
+<item>
+<title>Item title</title>
+<m:title>Media title</m:title>
+<c:encoded>Encoded via an unusual prefix</c:encoded>
+<content:encoded>Not the content module</content:encoded>
+<comments>http://example.com/comments</comments>
+<slash:comments>12</slash:comments>
+</item>
+</channel>
+</rss>`
+
+	f := new(Feed)
+	f.Title = "Namespaces"
+
+	e := new(Entry)
+	e.Title = "Item title"
+	e.Encoded = "Encoded via an unusual prefix"
+	e.Comments = "http://example.com/comments"
+
+	testContent("Namespaces", content, f, []*Entry{e}, t)
+}
+
+func Test_DefaultNamespace(t *testing.T) {
+	var content = `<rss xmlns="http://backend.userland.com/rss2"><channel>
+<title>Default RSS namespace</title>
+<item xmlns:a="http://www.w3.org/2005/Atom"><title>Item</title><a:summary>Atom summary</a:summary></item>
+<item><atom:summary>Undeclared, so conventional</atom:summary><x:title xmlns:x="http://www.w3.org/2005/Atom">Declared on itself</x:title></item>
+</channel></rss>`
+
+	f := new(Feed)
+	f.Title = "Default RSS namespace"
+
+	e1 := new(Entry)
+	e1.Title = "Item"
+	e1.Summary = "Atom summary"
+
+	e2 := new(Entry)
+	e2.Title = "Declared on itself"
+	e2.Summary = "Undeclared, so conventional"
+
+	testContent("Default namespace", content, f, []*Entry{e1, e2}, t)
+}
+
+func Test_DoctypeAndStylesheet(t *testing.T) {
+	content := `<?xml version="1.0"?>
+<?xml-stylesheet type="text/xsl" href="rss.xsl"?>
+<!DOCTYPE rss PUBLIC "-//Netscape Communications//DTD RSS 0.91//EN"
+  "http://my.netscape.com/publish/formats/rss-0.91.dtd">
+<rss version="0.91">
+<channel>
+<title>Old School</title>
+<item><title>First [and only]</title></item>
+</channel>
+</rss>`
+
+	feed, entries, err := NewParser("rss 0.91", content).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmpStr("feed Title", "Old School", feed.Title, t)
+	if len(entries) != 1 {
+		t.Fatalf("entry count (%d) not as expected (1)", len(entries))
+	}
+	cmpStr("entry Title", "First [and only]", entries[0].Title, t)
+}
+
+func Test_DeclaredCharset(t *testing.T) {
+	content := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<rss><channel><title>Caf\xe9 \x93quoted\x94</title></channel></rss>"
+
+	feed, _, err := NewParser("latin-1", content).Parse()
+	if err != nil {
+		t.Error(err)
+	}
+	cmpStr("feed Title", "Café “quoted”", feed.Title, t)
+
+	feed, _, err = NewParserFromReader("latin-1", iotest.OneByteReader(strings.NewReader(content))).Parse()
+	if err != nil {
+		t.Error(err)
+	}
+	cmpStr("feed Title", "Café “quoted”", feed.Title, t)
+}
+
+func Test_ContentTypeCharset(t *testing.T) {
+	// the header wins over the declaration
+	content := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><rss><channel><title>\xcf\xf0\xe8\xe2\xe5\xf2</title></channel></rss>"
+
+	parser := NewParserWithContentType("cp1251", strings.NewReader(content), "application/rss+xml; charset=windows-1251")
+	feed, _, err := parser.Parse()
+	if err != nil {
+		t.Error(err)
+	}
+	cmpStr("feed Title", "Привет", feed.Title, t)
+}
+
+func Test_UTF16ByteOrderMark(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-16"?><rss><channel><title>Ünïcödé 𝄞</title></channel></rss>`
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFF, 0xFE})
+	for _, u := range utf16.Encode([]rune(content)) {
+		buf.Write([]byte{byte(u), byte(u >> 8)})
+	}
+
+	parser := NewParserFromReader("utf-16", iotest.OneByteReader(&buf))
+	feed, _, err := parser.Parse()
+	if err != nil {
+		t.Error(err)
+	}
+	cmpStr("feed Title", "Ünïcödé 𝄞", feed.Title, t)
+}
+
+func Test_UnknownCharset(t *testing.T) {
+	content := `<?xml version="1.0" encoding="x-made-up"?><rss><channel><title>Plain</title></channel></rss>`
+
+	feed, _, err := NewParser("unknown charset", content).Parse()
+	if err != nil {
+		t.Error(err)
+	}
+	cmpStr("feed Title", "Plain", feed.Title, t)
+
+	parser := NewParser("unknown charset", content)
+	parser.SetLenient(true)
+	_, _, err = parser.Parse()
+	if warnings, ok := err.(LexErrorList); !ok || len(warnings) != 1 {
+		t.Errorf("Expected a warning about the charset, received %v", err)
+	}
+}
+
 func testContent(name, content string, expF *Feed, expEs []*Entry, t *testing.T) {
 	actF, actEs := parseFeed(name, content, t)
 	// cmpInt64("Id", expF.Id, actF.Id, t)
@@ -113,6 +1565,7 @@ func testContent(name, content string, expF *Feed, expEs []*Entry, t *testing.T)
 	cmpStr("feed Generator", expF.Generator, actF.Generator, t)
 	cmpStr("feed Logo", expF.Logo, actF.Logo, t)
 	cmpStr("feed Icon", expF.Icon, actF.Icon, t)
+	cmpImage("feed Image", expF.Image, actF.Image, t)
 
 	if len(expEs) != len(actEs) {
 		t.Error(fmt.Sprintf("Lenght of slices are not equal. Expected slice length: %d, while actual slice entries length: %d"))
@@ -124,8 +1577,10 @@ func testContent(name, content string, expF *Feed, expEs []*Entry, t *testing.T)
 		cmpStr("entry Link", expected.Link, actual.Link, t)
 		cmpStr("entry Subtitle", expected.Subtitle, actual.Subtitle, t)
 		cmpStr("entry Guid", expected.Guid, actual.Guid, t)
+		cmpStr("entry Author", expected.Author, actual.Author, t)
 		//cmpTime("entry UpdatedDate", expected.UpdatedDate, actual.UpdatedDate, t)
 		cmpStr("entry Summary", expected.Summary, actual.Summary, t)
+		cmpStr("entry Encoded", expected.Encoded, actual.Encoded, t)
 		cmpStr("entry Content", expected.Content, actual.Content, t)
 		cmpStr("entry Source", expected.Source, actual.Source, t)
 		cmpStr("entry Comments", expected.Comments, actual.Comments, t)
@@ -142,6 +1597,32 @@ func cmpStr(name, expected, actual string, t *testing.T) {
 	}
 }
 
+func cmpImage(name string, expected, actual *Image, t *testing.T) {
+	if expected == nil || actual == nil {
+		if expected != actual {
+			t.Error(fmt.Sprintf("Error with %s. Expected %v, received %v.", name, expected, actual))
+		}
+		return
+	}
+	if *expected != *actual {
+		t.Error(fmt.Sprintf("Error with %s. Expected %+v, received %+v.", name, *expected, *actual))
+	}
+}
+func cmpCategories(name string, expected, actual []Category, t *testing.T) {
+	if !reflect.DeepEqual(expected, actual) {
+		t.Error(fmt.Sprintf("Error with %s. Expected %+v, received %+v.", name, expected, actual))
+	}
+}
+func cmpPeople(name string, expected, actual []Person, t *testing.T) {
+	if !reflect.DeepEqual(expected, actual) {
+		t.Error(fmt.Sprintf("Error with %s. Expected %+v, received %+v.", name, expected, actual))
+	}
+}
+func cmpEnclosures(name string, expected, actual []Enclosure, t *testing.T) {
+	if !reflect.DeepEqual(expected, actual) {
+		t.Error(fmt.Sprintf("Error with %s. Expected %+v, received %+v.", name, expected, actual))
+	}
+}
 func cmpTime(name string, expected, actual time.Time, t *testing.T) {
 	if expected != actual {
 		t.Error(fmt.Sprintf("Error with %s. Expected %v, received %v.", name, expected, actual))
@@ -157,6 +1638,19 @@ func parseFeed(name, content string, t *testing.T) (feed *Feed, entries []*Entry
 	return
 }
 
+var atomWithoutNamespaceContent = `<?xml version="1.0" encoding="utf-8"?>
+<feed>
+	<title>No namespace</title>
+	<link href="http://example.org/"/>
+	<updated>2003-12-13T18:30:02Z</updated>
+	<entry>
+		<title>Still Atom</title>
+		<link href="http://example.org/still-atom"/>
+		<id>urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a</id>
+		<updated>2003-12-13T18:30:02Z</updated>
+	</entry>
+</feed>`
+
 var suttersMillContent string = `
 <rss xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:wfw="http://wellformedweb.org/CommentAPI/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/" xmlns:slash="http://purl.org/rss/1.0/modules/slash/" xmlns:georss="http://www.georss.org/georss" xmlns:geo="http://www.w3.org/2003/01/geo/wgs84_pos#" xmlns:media="http://search.yahoo.com/mrss/" version="2.0">
 <channel>
@@ -236,3 +1730,239 @@ var ericLippertContent = `
 
 var programmingReddit string = `
 <?xml version="1.0" encoding="UTF-8"?><rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:media="http://search.yahoo.com/mrss/" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>programming</title><link>http://www.reddit.com/r/programming/</link><description>Computer Programming</description><image><url>http://static.reddit.com/reddit_programming.png</url><title>programming</title><link>http://www.reddit.com/r/programming/</link></image><atom:link rel="self" href="http://www.reddit.com/r/programming/.rss" type="application/rss+xml" /><item><title>Which browsers crash the most?</title><link>http://www.reddit.com/r/programming/comments/1kuw60/which_browsers_crash_the_most/</link><guid isPermaLink="true">http://www.reddit.com/r/programming/comments/1kuw60/which_browsers_crash_the_most/</guid><pubDate>Thu, 22 Aug 2013 05:48:56 +0000</pubDate><description>submitted by &lt;a href=&#34;http://www.reddit.com/user/nnethercote&#34;&gt; nnethercote &lt;/a&gt; &lt;br/&gt; &lt;a href=&#34;http://sauceio.com/index.php/2013/08/the-surprising-worst-browser-the-reboot/&#34;&gt;[link]&lt;/a&gt; &lt;a href="http://www.reddit.com/r/programming/comments/1kuw60/which_browsers_crash_the_most/"&gt;[12 comments]&lt;/a&gt;</description></item></channel></rss>`
+
+var atomContent = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="text">Example Feed</title>
+  <subtitle type="html">A subtitle.</subtitle>
+  <updated>2005-07-31T12:29:29Z</updated>
+  <id>tag:example.org,2003:3</id>
+  <link rel="alternate" type="text/html" hreflang="en" href="http://example.org/"/>
+  <link rel="self" type="application/atom+xml" href="http://example.org/feed.atom"/>
+  <rights>Copyright (c) 2003, Mark Pilgrim</rights>
+  <generator uri="http://www.example.com/" version="1.0">Example Toolkit</generator>
+  <category term="examples" label="Examples"/>
+  <author>
+    <name>John Doe</name>
+    <email>johndoe@example.com</email>
+  </author>
+  <entry>
+    <title>Atom-Powered Robots Run Amok</title>
+    <link rel="alternate" type="text/html" href="http://example.org/2003/12/13/atom03"/>
+    <link rel="enclosure" type="audio/mpeg" length="1337" href="http://example.org/audio/ph34r_my_podcast.mp3"/>
+    <link rel="replies" type="text/html" href="http://example.org/2003/12/13/atom03/comments"/>
+    <id>urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a</id>
+    <published>2003-12-13T08:29:29-04:00</published>
+    <updated>2003-12-13T18:30:02Z</updated>
+    <author>
+      <name>Jane Doe</name>
+      <uri>http://example.org/jane</uri>
+    </author>
+    <summary>Some text &amp; more.</summary>
+    <content type="xhtml" xml:lang="en">
+      <div xmlns="http://www.w3.org/1999/xhtml">
+        <p>Robots &amp; androids are <em>running amok</em>.</p>
+      </div>
+    </content>
+  </entry>
+  <entry>
+    <title>Second</title>
+    <source>
+      <id>urn:uuid:elsewhere</id>
+      <title>Elsewhere</title>
+      <updated>2003-12-01T00:00:00Z</updated>
+    </source>
+    <link href="http://example.org/2003/12/14/second"/>
+    <id>urn:uuid:2</id>
+    <content type="html">&lt;b&gt;bold&lt;/b&gt; move</content>
+  </entry>
+</feed>`
+
+var rss10Content = `<?xml version="1.0"?>
+<rdf:RDF
+  xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+  xmlns:dc="http://purl.org/dc/elements/1.1/"
+  xmlns="http://purl.org/rss/1.0/">
+  <channel rdf:about="http://www.xml.com/xml/news.rss">
+    <title>XML.com</title>
+    <link>http://xml.com/pub</link>
+    <description>XML.com features a rich mix of information and services for the XML community.</description>
+    <dc:date>2000-08-09T04:00:00Z</dc:date>
+    <image rdf:resource="http://xml.com/universal/images/xml_tiny.gif" />
+    <items>
+      <rdf:Seq>
+        <rdf:li resource="http://xml.com/pub/2000/08/09/xslt/xslt.html" />
+        <rdf:li resource="http://xml.com/pub/2000/08/09/rdfdb/index.html" />
+      </rdf:Seq>
+    </items>
+  </channel>
+  <image rdf:about="http://xml.com/universal/images/xml_tiny.gif">
+    <title>XML.com logo</title>
+    <link>http://www.xml.com</link>
+    <url>http://xml.com/universal/images/xml_tiny.gif</url>
+  </image>
+  <item rdf:about="http://xml.com/pub/2000/08/09/xslt/xslt.html">
+    <title>Processing Inclusions with XSLT</title>
+    <link>http://xml.com/pub/2000/08/09/xslt/xslt.html</link>
+    <description>Processing document inclusions with general XML tools can be problematic.</description>
+    <dc:date>2000-08-09T11:22:33Z</dc:date>
+  </item>
+  <item rdf:about="http://xml.com/pub/2000/08/09/rdfdb/">
+    <title>Putting RDF to Work</title>
+    <link>http://xml.com/pub/2000/08/09/rdfdb/index.html</link>
+  </item>
+  <textinput rdf:about="http://search.xml.com">
+    <title>Search XML.com</title>
+    <link>http://search.xml.com</link>
+  </textinput>
+</rdf:RDF>`
+
+var jsonFeedContent = `{
+    "version": "https://jsonfeed.org/version/1.1",
+    "title": "My Example Feed",
+    "home_page_url": "https://example.org/",
+    "feed_url": "https://example.org/feed.json",
+    "description": "Notes & links",
+    "icon": "https://example.org/icon.png",
+    "favicon": "https://example.org/favicon.ico",
+    "language": "en-US",
+    "authors": [{"name": "Brent", "url": "https://example.org/brent"}],
+    "items": [
+        {
+            "id": 2,
+            "url": "https://example.org/second-item",
+            "title": "Second",
+            "content_html": "<p>Hello, world!</p>",
+            "summary": "A greeting & a wave",
+            "image": "https://example.org/second.png",
+            "date_published": "2010-02-07T14:04:00-00:00",
+            "date_modified": "2010-02-08T09:00:00Z",
+            "authors": [{"name": "Manton"}],
+            "attachments": [
+                {"url": "https://example.org/second.m4a", "mime_type": "audio/x-m4a", "size_in_bytes": 89970236},
+                {"url": "https://example.org/second.mp3", "mime_type": "audio/mpeg"}
+            ],
+            "tags": ["greetings", "examples"]
+        },
+        {
+            "id": "https://example.org/first-item",
+            "external_url": "https://elsewhere.example.com/",
+            "content_text": "1 < 2 & 3",
+            "date_published": "2010-02-07T09:00:00+10:00"
+        }
+    ]
+}`
+
+var atom03Content = `<?xml version="1.0" encoding="utf-8"?>
+<feed version="0.3" xmlns="http://purl.org/atom/ns#" xml:lang="en">
+  <title>dive into mark</title>
+  <link rel="alternate" type="text/html" href="http://diveintomark.org/"/>
+  <tagline>A lot of effort went into making this effortless</tagline>
+  <modified>2003-12-13T18:30:02Z</modified>
+  <copyright>Copyright (c) 2003, Mark Pilgrim</copyright>
+  <author><name>Mark Pilgrim</name></author>
+  <entry>
+    <title>Atom 0.3 snapshot</title>
+    <link rel="alternate" type="text/html" href="http://diveintomark.org/2003/12/13/atom03"/>
+    <id>tag:diveintomark.org,2003:3.2397</id>
+    <issued>2003-12-13T08:29:29-04:00</issued>
+    <modified>2003-12-13T18:30:02Z</modified>
+    <summary type="text/plain">The snapshot</summary>
+    <content type="text/html" mode="escaped">&lt;p&gt;Escaped &lt;em&gt;html&lt;/em&gt;&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <title>Inline</title>
+    <id>tag:diveintomark.org,2003:3.2398</id>
+    <content type="application/xhtml+xml" mode="xml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Inline <b>xml</b></p></div></content>
+    <content type="image/png" mode="base64">iVBORw0KGgo=</content>
+  </entry>
+</feed>`
+
+// based on the example feed of the Podcasting 2.0 namespace
+var podcastContent = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:podcast="https://podcastindex.org/namespace/1.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Podcasting 2.0 Namespace Example</title>
+    <description>This is a fake show that exists only as an example of the "podcast" namespace tag usage.</description>
+    <link>https://example.com/podcast</link>
+    <atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+    <language>en-US</language>
+    <podcast:guid>917393e3-1b1e-5cef-ace4-edaa54e1f810</podcast:guid>
+    <podcast:locked owner="podcastowner@example.com">yes</podcast:locked>
+    <podcast:funding url="https://www.example.com/donations">Support the show!</podcast:funding>
+    <podcast:person href="https://www.wikipedia/alicebrown" img="http://example.com/images/alicebrown.jpg">Alice Brown</podcast:person>
+    <itunes:author>John Doe</itunes:author>
+    <itunes:owner>
+      <itunes:name>John Doe</itunes:name>
+      <itunes:email>johndoe@example.com</itunes:email>
+    </itunes:owner>
+    <itunes:image href="https://example.com/images/pci_avatar-massive.jpg"/>
+    <itunes:explicit>false</itunes:explicit>
+    <itunes:type>episodic</itunes:type>
+    <itunes:category text="Technology">
+      <itunes:category text="Podcasting"/>
+    </itunes:category>
+    <itunes:category text="News">
+      <itunes:category text="Tech News"/>
+      <itunes:category text="Politics"></itunes:category>
+    </itunes:category>
+    <item>
+      <title>Episode 3 - The Future</title>
+      <description>&lt;p&gt;A short description of the episode.&lt;/p&gt;</description>
+      <link>https://example.com/podcast/ep0003</link>
+      <guid isPermaLink="true">https://example.com/ep0003</guid>
+      <pubDate>Fri, 09 Oct 2020 04:30:38 GMT</pubDate>
+      <author>John Doe (john@example.com)</author>
+      <itunes:image href="https://example.com/ep0003/artMd.jpg"/>
+      <itunes:duration>1:02:03</itunes:duration>
+      <itunes:episode>3</itunes:episode>
+      <itunes:season>2</itunes:season>
+      <itunes:episodeType>full</itunes:episodeType>
+      <itunes:explicit>yes</itunes:explicit>
+      <podcast:season>2</podcast:season>
+      <podcast:episode>3</podcast:episode>
+      <podcast:chapters url="https://example.com/ep3_chapters.json" type="application/json"/>
+      <podcast:soundbite startTime="33.833" duration="60.0"/>
+      <podcast:person role="guest" href="https://www.wikipedia/janedoe" img="http://example.com/images/janedoe.jpg">Jane Doe</podcast:person>
+      <podcast:person group="visuals" role="cover art designer" href="https://www.wikipedia/beckysmith">Becky Smith</podcast:person>
+      <podcast:transcript url="https://example.com/ep3/transcript.txt" type="text/plain"/>
+      <podcast:transcript url="https://example.com/ep3/transcript.vtt" type="text/vtt" language="es" rel="captions"/>
+      <enclosure url="https://example.com/file-03.mp3" length="43200000" type="audio/mpeg"/>
+    </item>
+    <item>
+      <title>Episode 2 - The Present</title>
+      <guid isPermaLink="true">https://example.com/ep0002</guid>
+      <itunes:duration>1815</itunes:duration>
+      <enclosure url="https://example.com/file-02.mp3" length="43200000" type="audio/mpeg"/>
+    </item>
+  </channel>
+</rss>`
+
+var mediaContent = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+<title>Media RSS</title>
+<item>
+  <title>Big Buck Bunny</title>
+  <link>http://example.com/bbb</link>
+  <media:group>
+    <media:title>Big Buck Bunny</media:title>
+    <media:content url="http://example.com/bbb/480.mp4" fileSize="64657027" type="video/mp4" medium="video" isDefault="true" duration="596" width="854" height="480"/>
+    <media:content url="http://example.com/bbb/720.mp4" type="video/mp4" medium="video" duration="596.5" width="1280" height="720">
+      <media:thumbnail url="http://example.com/bbb/large.jpg" width="1280" height="720"/>
+      <media:description type="html">&lt;b&gt;HD&lt;/b&gt;</media:description>
+    </media:content>
+    <media:thumbnail url="http://example.com/bbb/small.jpg" width="120" height="90"/>
+    <media:community><media:starRating average="4.5" count="10"/></media:community>
+  </media:group>
+  <media:description>A large rabbit &amp; three rodents</media:description>
+  <media:credit role="producer" scheme="urn:ebu">Blender Foundation</media:credit>
+</item>
+<item>
+  <title>Gallery</title>
+  <media:content url="http://example.com/gallery/1.jpg" medium="image" width="640" height="480"/>
+  <media:content url="http://example.com/gallery/2.jpg" type="image/jpeg" width="1024" height="768"/>
+  <media:content url="http://example.com/gallery/3.png" medium="image"/>
+</item>
+</channel>
+</rss>`
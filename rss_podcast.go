@@ -0,0 +1,278 @@
+package rss
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Podcast holds a feed's iTunes (itunes:) and Podcasting 2.0 (podcast:)
+// elements
+type Podcast struct {
+	Image      string // itunes:image
+	Explicit   Explicit
+	Categories []PodcastCategory
+	Guid       string // podcast:guid, which stays the same if the feed moves
+	Persons    []PodcastPerson
+}
+
+// PodcastEpisode holds an entry's iTunes and Podcasting 2.0 elements
+type PodcastEpisode struct {
+	Duration    time.Duration
+	Episode     int // zero if not given
+	Season      int // zero if not given
+	Explicit    Explicit
+	Image       string
+	Transcripts []PodcastTranscript
+	Chapters    *PodcastChapters // nil if not given
+	Persons     []PodcastPerson
+}
+
+// Explicit is whether a podcast or episode says it has explicit content
+type Explicit int
+
+const (
+	ExplicitUnknown Explicit = iota // it doesn't say
+	ExplicitNo                      // "false", "no" or "clean"
+	ExplicitYes                     // "true", "yes" or "explicit"
+)
+
+// PodcastCategory is one of Apple's podcast categories, e.g. Technology,
+// which may have subcategories of its own, e.g. Tech News
+type PodcastCategory struct {
+	Name          string
+	Subcategories []PodcastCategory
+}
+
+// PodcastTranscript is a transcript or captions of an episode
+type PodcastTranscript struct {
+	URL      string
+	Type     string // e.g. text/vtt or application/srt
+	Language string
+	Rel      string // "captions" if the transcript is also captions
+}
+
+// PodcastChapters is a file of an episode's chapters
+type PodcastChapters struct {
+	URL  string
+	Type string // usually application/json+chapters
+}
+
+// PodcastPerson is someone who takes part in a podcast or episode
+type PodcastPerson struct {
+	Name  string
+	Role  string // e.g. host or guest, host being the default
+	Group string // e.g. cast or writing, cast being the default
+	Img   string
+	Href  string
+}
+
+// podcastOf returns the feed's podcast elements, adding them if need be
+func podcastOf(feed *Feed) *Podcast {
+	if feed.Podcast == nil {
+		feed.Podcast = new(Podcast)
+	}
+	return feed.Podcast
+}
+
+// episodeOf returns the entry's podcast elements, adding them if need be
+func episodeOf(entry *Entry) *PodcastEpisode {
+	if entry.Podcast == nil {
+		entry.Podcast = new(PodcastEpisode)
+	}
+	return entry.Podcast
+}
+
+// Feed handlers
+
+func handleFeedItunesImage(e *Element, feed *Feed) {
+	if image := extractItunesImage(e); image != "" {
+		podcastOf(feed).Image = image
+	}
+}
+
+func handleFeedItunesExplicit(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		podcastOf(feed).Explicit = parseExplicit(text)
+	}
+}
+
+func handleFeedItunesCategory(e *Element, feed *Feed) {
+	if category := extractItunesCategory(e); category.Name != "" {
+		podcast := podcastOf(feed)
+		podcast.Categories = append(podcast.Categories, category)
+	}
+}
+
+func handleFeedPodcastGuid(e *Element, feed *Feed) {
+	if text := e.Text(); text != "" {
+		podcastOf(feed).Guid = strings.TrimSpace(text)
+	}
+}
+
+func handleFeedPodcastPerson(e *Element, feed *Feed) {
+	if person, ok := extractPodcastPerson(e); ok {
+		podcast := podcastOf(feed)
+		podcast.Persons = append(podcast.Persons, person)
+	}
+}
+
+// Entry handlers
+
+func handleEntryItunesDuration(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		if duration, ok := parseItunesDuration(text); ok {
+			episodeOf(entry).Duration = duration
+		}
+	}
+}
+
+func handleEntryItunesEpisode(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		if episode, err := strconv.Atoi(strings.TrimSpace(text)); err == nil && episode > 0 {
+			episodeOf(entry).Episode = episode
+		}
+	}
+}
+
+func handleEntryItunesSeason(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		if season, err := strconv.Atoi(strings.TrimSpace(text)); err == nil && season > 0 {
+			episodeOf(entry).Season = season
+		}
+	}
+}
+
+func handleEntryItunesExplicit(e *Element, entry *Entry) {
+	if text := e.Text(); text != "" {
+		episodeOf(entry).Explicit = parseExplicit(text)
+	}
+}
+
+func handleEntryItunesImage(e *Element, entry *Entry) {
+	if image := extractItunesImage(e); image != "" {
+		episodeOf(entry).Image = image
+	}
+}
+
+// handleEntryPodcastTranscript handles <podcast:transcript url="..."
+// type="..." language="..." rel="captions"/>, of which there may be one
+// for each format
+func handleEntryPodcastTranscript(e *Element, entry *Entry) {
+	if url := e.ResolveURL(e.Attr("url")); url != "" {
+		episode := episodeOf(entry)
+		episode.Transcripts = append(episode.Transcripts, PodcastTranscript{
+			URL:      url,
+			Type:     e.Attr("type"),
+			Language: e.Attr("language"),
+			Rel:      e.Attr("rel"),
+		})
+	}
+}
+
+func handleEntryPodcastChapters(e *Element, entry *Entry) {
+	if url := e.ResolveURL(e.Attr("url")); url != "" {
+		episodeOf(entry).Chapters = &PodcastChapters{URL: url, Type: e.Attr("type")}
+	}
+}
+
+func handleEntryPodcastPerson(e *Element, entry *Entry) {
+	if person, ok := extractPodcastPerson(e); ok {
+		episode := episodeOf(entry)
+		episode.Persons = append(episode.Persons, person)
+	}
+}
+
+// extractItunesImage reads <itunes:image href="..."/>, or the url as text,
+// which some feeds give instead
+func extractItunesImage(e *Element) string {
+	if href, ok := e.LookupAttr("href"); ok {
+		return e.ResolveURL(href)
+	}
+	if text := e.Text(); text != "" {
+		return e.ResolveURL(strings.TrimSpace(text))
+	}
+	return ""
+}
+
+// extractItunesCategory reads <itunes:category text="...">, along with
+// the categories nested in it
+func extractItunesCategory(e *Element) PodcastCategory {
+	category := PodcastCategory{Name: e.Attr("text")}
+	e.Children(func(child *Element) {
+		if child.Namespace != nsItunes || child.Name != "category" {
+			return
+		}
+		if subcategory := extractItunesCategory(child); subcategory.Name != "" {
+			category.Subcategories = append(category.Subcategories, subcategory)
+		}
+	})
+	return category
+}
+
+// extractPodcastPerson reads <podcast:person role="..." group="..."
+// img="..." href="...">name</podcast:person>
+func extractPodcastPerson(e *Element) (PodcastPerson, bool) {
+	text := e.Text()
+	if text == "" {
+		return PodcastPerson{}, false
+	}
+	person := PodcastPerson{
+		Name:  strings.TrimSpace(text),
+		Role:  e.Attr("role"),
+		Group: e.Attr("group"),
+		Img:   e.ResolveURL(e.Attr("img")),
+		Href:  e.ResolveURL(e.Attr("href")),
+	}
+	if person.Role == "" {
+		person.Role = "host"
+	}
+	if person.Group == "" {
+		person.Group = "cast"
+	}
+	return person, true
+}
+
+// parseExplicit reads the value of itunes:explicit. Apple now asks for
+// true or false, but yes, no, clean and explicit are all still about.
+func parseExplicit(value string) Explicit {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "explicit":
+		return ExplicitYes
+	case "false", "no", "clean":
+		return ExplicitNo
+	}
+	return ExplicitUnknown
+}
+
+// parseItunesDuration reads an itunes:duration, which is a number of
+// seconds, or HH:MM:SS or MM:SS, the hours and minutes not always
+// zero-padded
+func parseItunesDuration(value string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	seconds := 0.0
+	for _, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	return secondsDuration(seconds)
+}
+
+// maxDurationSeconds is the longest time.Duration, in seconds
+const maxDurationSeconds = float64(math.MaxInt64 / int64(time.Second))
+
+// secondsDuration is a number of seconds as a time.Duration, if it's one
+// that fits: not negative, NaN, infinite or more than a Duration can hold
+func secondsDuration(seconds float64) (time.Duration, bool) {
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) || seconds < 0 || seconds > maxDurationSeconds {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
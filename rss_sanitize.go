@@ -0,0 +1,254 @@
+package rss
+
+import (
+	"bytes"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// Sanitizer cleans the html of feeds, which comes from third parties, so
+// that it can be shown to readers. It works from an allowlist: only the
+// elements and attributes it names are kept, so there's no script, event
+// handler or javascript: URL left to run. Its fields may be changed to
+// suit, before it's first used.
+type Sanitizer struct {
+	// Elements are the elements kept, each with the attributes it may
+	// keep. Other elements are dropped, but what's in them is kept.
+	Elements map[string][]string
+
+	// Attributes are those any element kept may keep, e.g. title
+	Attributes []string
+
+	// DropContent are the elements dropped along with everything in them,
+	// e.g. script
+	DropContent map[string]bool
+
+	// URLSchemes are the schemes an href, src, cite or poster may have.
+	// Attributes with any other scheme are dropped; relative URLs are
+	// always kept.
+	URLSchemes map[string]bool
+
+	// KeepTrackingPixels keeps images no bigger than a pixel, which are
+	// otherwise dropped as they're only there to track readers
+	KeepTrackingPixels bool
+}
+
+// DefaultSanitizer returns a Sanitizer that keeps the formatting, links,
+// images, lists, tables and media of an article, and nothing that runs or
+// styles it
+func DefaultSanitizer() *Sanitizer {
+	return &Sanitizer{
+		Elements: map[string][]string{
+			"a": {"href"}, "abbr": nil, "b": nil, "blockquote": {"cite"},
+			"br": nil, "caption": nil, "cite": nil, "code": nil, "dd": nil,
+			"del": {"cite", "datetime"}, "details": nil, "dfn": nil,
+			"div": nil, "dl": nil, "dt": nil, "em": nil, "figcaption": nil,
+			"figure": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil,
+			"h5": nil, "h6": nil, "hr": nil, "i": nil,
+			"img": {"src", "alt", "width", "height"},
+			"ins": {"cite", "datetime"}, "kbd": nil, "li": nil, "mark": nil,
+			"ol": {"start", "reversed"}, "p": nil, "pre": nil, "q": {"cite"},
+			"s": nil, "samp": nil, "small": nil, "span": nil, "strike": nil,
+			"strong": nil, "sub": nil, "summary": nil, "sup": nil,
+			"table": nil, "tbody": nil, "td": {"colspan", "rowspan"},
+			"tfoot": nil, "th": {"colspan", "rowspan", "scope"},
+			"thead": nil, "time": {"datetime"}, "tr": nil, "u": nil,
+			"ul": nil, "var": nil,
+			"audio":  {"src", "controls"},
+			"video":  {"src", "controls", "poster", "width", "height"},
+			"source": {"src", "type"},
+		},
+		Attributes: []string{"title", "lang", "dir"},
+		DropContent: map[string]bool{
+			"script": true, "style": true, "iframe": true, "object": true,
+			"embed": true, "applet": true, "noscript": true, "template": true,
+			"head": true, "title": true, "textarea": true, "select": true,
+			"svg": true, "math": true, "frame": true, "frameset": true,
+		},
+		URLSchemes: map[string]bool{
+			"http": true, "https": true, "mailto": true,
+		},
+	}
+}
+
+// the attributes that hold URLs
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "cite": true, "poster": true,
+}
+
+// SanitizeEntry sanitizes the entry's summary, encoded content and
+// content, keeping them as they were in Original. Sanitizing an entry a
+// second time keeps the Original from the first.
+func (s *Sanitizer) SanitizeEntry(entry *Entry) {
+	if entry.Original == nil {
+		entry.Original = &EntryHtml{
+			Summary: entry.Summary,
+			Encoded: entry.Encoded,
+			Content: entry.Content,
+		}
+	}
+	entry.Summary = s.Sanitize(entry.Summary)
+	entry.Encoded = s.Sanitize(entry.Encoded)
+	entry.Content = s.Sanitize(entry.Content)
+}
+
+// Sanitize returns content, html, with everything but the elements and
+// attributes the sanitizer allows taken out. Elements left open are
+// closed, and text is escaped, so the result is well formed.
+func (s *Sanitizer) Sanitize(content string) string {
+	if !strings.ContainsAny(content, "<&>") {
+		return content
+	}
+
+	var buf bytes.Buffer
+	var open []string // the elements written and not yet closed
+	dropping := 0     // the depth in an element being dropped with its content
+
+	l := newNsReader(lexHtml(content))
+	lexeme := l.nextItem()
+	for !lexeme.isEnd() {
+		switch lexeme.typ {
+		case itemText, itemHtml:
+			if dropping == 0 {
+				buf.WriteString(html.EscapeString(string(lexeme.val)))
+			}
+		case itemOpenTag:
+			tag := htmlTagName(lexeme)
+			var attrs [][2]string
+			for lexeme = l.nextItem(); lexeme.typ == itemAttributeName; {
+				name := strings.ToLower(qualifiedName(lexeme))
+				if lexeme = l.nextItem(); lexeme.typ == itemAttributeValue {
+					attrs = append(attrs, [2]string{name, string(lexeme.val)})
+					lexeme = l.nextItem()
+				}
+			}
+			selfClosing := lexeme.typ == itemSelfClosingTag
+			if selfClosing {
+				lexeme = l.nextItem()
+			}
+			hasContent := !selfClosing && !voidElements[tag]
+
+			switch {
+			case dropping > 0:
+				if hasContent {
+					dropping++
+				}
+			case s.DropContent[tag]:
+				if hasContent {
+					dropping = 1
+				}
+			case s.isAllowed(tag):
+				if tag == "img" && !s.KeepTrackingPixels && isTrackingPixel(attrs) {
+					continue
+				}
+				s.writeTag(&buf, tag, attrs)
+				if selfClosing && !voidElements[tag] {
+					buf.WriteString("</" + tag + ">")
+				} else if hasContent {
+					open = append(open, tag)
+				}
+			}
+			continue
+		case itemCloseTag:
+			if dropping > 0 {
+				dropping--
+				break
+			}
+			tag := htmlTagName(lexeme)
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == tag {
+					for j := len(open) - 1; j >= i; j-- {
+						buf.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+		lexeme = l.nextItem()
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		buf.WriteString("</" + open[i] + ">")
+	}
+	return buf.String()
+}
+
+// isAllowed reports whether tag is one of the sanitizer's elements
+func (s *Sanitizer) isAllowed(tag string) bool {
+	_, ok := s.Elements[tag]
+	return ok
+}
+
+// writeTag writes the start tag of an element that's allowed, with those
+// of its attributes that are
+func (s *Sanitizer) writeTag(buf *bytes.Buffer, tag string, attrs [][2]string) {
+	buf.WriteString("<" + tag)
+	for _, attr := range attrs {
+		name, value := attr[0], attr[1]
+		if !containsString(s.Elements[tag], name) && !containsString(s.Attributes, name) {
+			continue
+		}
+		if urlAttributes[name] && !s.allowedURL(value) {
+			continue
+		}
+		buf.WriteString(" " + name + "=\"" + html.EscapeString(value) + "\"")
+	}
+	buf.WriteString(">")
+}
+
+// allowedURL reports whether url is relative, or has one of the schemes
+// allowed. Browsers ignore whitespace and control characters in a URL's
+// scheme (java&#9;script:), so the sanitizer does too.
+func (s *Sanitizer) allowedURL(url string) bool {
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, url)
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 || strings.ContainsAny(cleaned[:colon], "/?#") {
+		return true
+	}
+	return s.URLSchemes[strings.ToLower(cleaned[:colon])]
+}
+
+// isTrackingPixel reports whether an image, given its attributes, is no
+// bigger than a pixel
+func isTrackingPixel(attrs [][2]string) bool {
+	for _, attr := range attrs {
+		if (attr[0] == "width" || attr[0] == "height") && isTrackingPixelSize(attr[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrackingPixelSize reports whether value, an image's width or height,
+// is no more than a pixel, which makes the image a tracking pixel. Both
+// sanitizing and picking thumbnails go by it, so they agree on which
+// images those are.
+func isTrackingPixelSize(value string) bool {
+	// an unquoted value may run into the end of a self-closing tag (1/>)
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "/"))
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(value, "px")))
+	return err == nil && n <= 1
+}
+
+// htmlTagName is the name of a tag, lower-cased as html is case
+// insensitive. Prefixed names, e.g. Word's <o:p>, keep their prefix, so
+// they're never taken for an html element.
+func htmlTagName(l lexeme) string {
+	return strings.ToLower(qualifiedName(l))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
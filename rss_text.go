@@ -0,0 +1,154 @@
+package rss
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// elements whose content isn't text to be read
+var hiddenElements = map[string]bool{
+	"script": true, "style": true, "head": true, "title": true,
+	"noscript": true, "template": true, "svg": true, "math": true,
+	"iframe": true, "object": true, "select": true, "textarea": true,
+}
+
+// elements that break the text, so the words either side of them aren't
+// run together
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"br": true, "caption": true, "dd": true, "details": true, "div": true,
+	"dl": true, "dt": true, "figcaption": true, "figure": true,
+	"footer": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "img": true,
+	"li": true, "main": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "summary": true, "table": true,
+	"td": true, "th": true, "tr": true, "ul": true,
+}
+
+// Text is the entry as plain text, from the fullest of its content,
+// encoded content and summary
+func (e *Entry) Text() string {
+	for _, content := range []string{e.Content, e.Encoded, e.Summary} {
+		if text := PlainText(content); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// Excerpt is the start of the entry as plain text, in no more than length
+// runes, for a preview. It's taken from the entry's summary if it has one,
+// as that's what the feed means to be shown, and otherwise from its Text.
+func (e *Entry) Excerpt(length int) string {
+	text := PlainText(e.Summary)
+	if text == "" {
+		text = e.Text()
+	}
+	return Excerpt(text, length)
+}
+
+// PlainText returns content, html, as plain text: with the markup taken
+// out, and what's hidden (e.g. scripts) along with it, entities decoded,
+// and runs of whitespace collapsed into a single space
+func PlainText(content string) string {
+	var text spaceCollapser
+	if !strings.ContainsAny(content, "<&") {
+		text.WriteString(content)
+		return text.String()
+	}
+
+	hidden := 0         // the depth in a hidden element
+	lastOpened := false // the last open tag was counted in hidden
+	l := lexHtml(content)
+	for lexeme := l.nextItem(); !lexeme.isEnd(); lexeme = l.nextItem() {
+		switch lexeme.typ {
+		case itemText, itemHtml:
+			if hidden == 0 {
+				text.Write(lexeme.val)
+			}
+		case itemOpenTag:
+			tag := strings.ToLower(string(lexeme.val))
+			lastOpened = (hidden > 0 || hiddenElements[tag]) && !voidElements[tag]
+			if lastOpened {
+				hidden++
+			} else if hidden == 0 && blockElements[tag] {
+				text.space()
+			}
+		case itemSelfClosingTag:
+			if lastOpened {
+				hidden--
+				lastOpened = false
+			}
+		case itemCloseTag:
+			lastOpened = false
+			if hidden > 0 {
+				hidden--
+			} else if blockElements[strings.ToLower(string(lexeme.val))] {
+				text.space()
+			}
+		}
+	}
+	return text.String()
+}
+
+// spaceCollapser builds text with each run of whitespace in it, non
+// breaking spaces included, collapsed into a single space, and none at
+// either end
+type spaceCollapser struct {
+	buf     []byte
+	pending bool // there's whitespace to write before anything else
+}
+
+func (c *spaceCollapser) Write(text []byte) {
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		if unicode.IsSpace(r) {
+			c.space()
+		} else {
+			if c.pending && len(c.buf) > 0 {
+				c.buf = append(c.buf, ' ')
+			}
+			c.pending = false
+			c.buf = append(c.buf, text[:size]...)
+		}
+		text = text[size:]
+	}
+}
+
+func (c *spaceCollapser) WriteString(text string) {
+	c.Write([]byte(text))
+}
+
+func (c *spaceCollapser) space() {
+	c.pending = true
+}
+
+func (c *spaceCollapser) String() string {
+	return string(c.buf)
+}
+
+// Excerpt returns the start of text, plain text with its whitespace
+// collapsed, in no more than length runes. Text that's cut short is cut
+// at the end of a word where there is one, and ends in an ellipsis.
+func Excerpt(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	if length <= 1 {
+		return ""
+	}
+
+	// leave room for the ellipsis
+	cut := 0
+	for i := 0; i < length-1; i++ {
+		_, size := utf8.DecodeRuneInString(text[cut:])
+		cut += size
+	}
+	if text[cut] != ' ' {
+		if space := strings.LastIndexByte(text[:cut], ' '); space > 0 {
+			cut = space
+		}
+	}
+	return strings.TrimRight(text[:cut], " ,;:-") + "…"
+}
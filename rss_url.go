@@ -0,0 +1,146 @@
+package rss
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// documentBase is the base URL of a feed called name: the name itself, if
+// it's an absolute URL, as it is when the feed was fetched from it
+func documentBase(name string) string {
+	u, err := url.Parse(name)
+	if err != nil || !u.IsAbs() {
+		return ""
+	}
+	return name
+}
+
+// resolveURL resolves ref, e.g. a link, against base. Absolute URLs are
+// left as they are, as is everything when there's no base or either
+// won't parse.
+func resolveURL(base, ref string) string {
+	if base == "" {
+		return ref
+	}
+	trimmed := strings.TrimSpace(ref)
+	if trimmed == "" {
+		return ref
+	}
+	refURL, err := url.Parse(trimmed)
+	if err != nil || refURL.IsAbs() {
+		return ref
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// resolveHtml resolves the src and href attributes of the tags in content
+// against base. Everything else, links within the page (#fragment)
+// included, is left byte for byte as it was.
+func resolveHtml(base, content string) string {
+	if base == "" {
+		return content
+	}
+	if lower := strings.ToLower(content); !strings.Contains(lower, "src") && !strings.Contains(lower, "href") {
+		return content
+	}
+
+	var buf strings.Builder
+	written := 0 // how much of content is in buf
+	for i := 0; i < len(content); {
+		open := strings.IndexByte(content[i:], '<')
+		if open < 0 {
+			break
+		}
+		i += open + 1
+		if i >= len(content) || !isAsciiLetter(content[i]) {
+			// a close tag, comment or stray <
+			continue
+		}
+		i = skipName(content, i)
+
+		for i < len(content) && content[i] != '>' && content[i] != '<' {
+			if isHtmlSpace(content[i]) || content[i] == '/' {
+				i++
+				continue
+			}
+			nameStart := i
+			i = skipName(content, i)
+			if i == nameStart {
+				// a quote or = where a name should be
+				i++
+				continue
+			}
+			name := content[nameStart:i]
+			for i < len(content) && isHtmlSpace(content[i]) {
+				i++
+			}
+			if i >= len(content) || content[i] != '=' {
+				continue
+			}
+			for i++; i < len(content) && isHtmlSpace(content[i]); i++ {
+			}
+
+			var valueStart, valueEnd int
+			if i < len(content) && (content[i] == '"' || content[i] == '\'') {
+				valueStart = i + 1
+				end := strings.IndexByte(content[valueStart:], content[i])
+				if end < 0 {
+					return finishResolve(&buf, content, written)
+				}
+				valueEnd = valueStart + end
+				i = valueEnd + 1
+			} else {
+				valueStart = i
+				for i < len(content) && !isHtmlSpace(content[i]) && content[i] != '>' {
+					i++
+				}
+				valueEnd = i
+			}
+
+			if !strings.EqualFold(name, "src") && !strings.EqualFold(name, "href") {
+				continue
+			}
+			value := html.UnescapeString(content[valueStart:valueEnd])
+			if strings.HasPrefix(strings.TrimSpace(value), "#") {
+				continue
+			}
+			if resolved := resolveURL(base, value); resolved != value {
+				buf.WriteString(content[written:valueStart])
+				buf.WriteString(html.EscapeString(resolved))
+				written = valueEnd
+			}
+		}
+	}
+	return finishResolve(&buf, content, written)
+}
+
+// finishResolve is the result of resolveHtml, buf and the rest of content
+func finishResolve(buf *strings.Builder, content string, written int) string {
+	if written == 0 {
+		return content
+	}
+	buf.WriteString(content[written:])
+	return buf.String()
+}
+
+// skipName returns the index of the first byte from i that can't be part
+// of a tag or attribute name
+func skipName(content string, i int) int {
+	for i < len(content) && !isHtmlSpace(content[i]) && !strings.ContainsRune("/>='\"<", rune(content[i])) {
+		i++
+	}
+	return i
+}
+
+func isAsciiLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isHtmlSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -25,7 +25,7 @@ func parseFile(filepath string) {
 
 	//db := NewRssDatabase("rss", "travis", "")
 
-	parser := NewParser(filepath, string(fileContents))
+	parser := NewParserFromBytes(filepath, fileContents)
 	feed, entries, err := parser.Parse()
 
 	fmt.Printf("Err: %v\nFeed: %v\nEntries: %v\n", err, feed, entries)
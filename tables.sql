@@ -26,7 +26,10 @@ CREATE TABLE `Entry` (
   `Link` varchar(2048) DEFAULT NULL,
   `Subtitle` varchar(4096) DEFAULT NULL,
   `Guid` varchar(512) DEFAULT NULL,
+  `PublishDate` datetime DEFAULT NULL,
   `UpdatedDate` datetime DEFAULT NULL,
+  `FirstSeen` datetime DEFAULT NULL,
+  `DateSource` varchar(16) DEFAULT NULL,
   `Summary` varchar(4096) DEFAULT NULL,
   `Content` varchar(4096) DEFAULT NULL,
   `Source` varchar(4096) DEFAULT NULL,
@@ -47,6 +50,70 @@ ALTER TABLE `rss`.`Entry`
   ON DELETE NO ACTION
   ON UPDATE NO ACTION;
 
+CREATE TABLE `FeedCategory` (
+  `Id` int(11) NOT NULL AUTO_INCREMENT,
+  `FeedId` int(11) NOT NULL,
+  `Term` varchar(256) DEFAULT NULL,
+  `Scheme` varchar(1024) DEFAULT NULL,
+  `Label` varchar(256) DEFAULT NULL,
+  PRIMARY KEY (`Id`),
+  KEY `fk_FeedCategory_Feed_idx` (`FeedId`),
+  CONSTRAINT `fk_FeedCategory_Feed` FOREIGN KEY (`FeedId`) REFERENCES `Feed` (`Id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE `FeedAuthor` (
+  `Id` int(11) NOT NULL AUTO_INCREMENT,
+  `FeedId` int(11) NOT NULL,
+  `Name` varchar(256) DEFAULT NULL,
+  `Email` varchar(256) DEFAULT NULL,
+  `URI` varchar(1024) DEFAULT NULL,
+  PRIMARY KEY (`Id`),
+  KEY `fk_FeedAuthor_Feed_idx` (`FeedId`),
+  CONSTRAINT `fk_FeedAuthor_Feed` FOREIGN KEY (`FeedId`) REFERENCES `Feed` (`Id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE `EntryCategory` (
+  `Id` int(11) NOT NULL AUTO_INCREMENT,
+  `EntryId` int(11) NOT NULL,
+  `Term` varchar(256) DEFAULT NULL,
+  `Scheme` varchar(1024) DEFAULT NULL,
+  `Label` varchar(256) DEFAULT NULL,
+  PRIMARY KEY (`Id`),
+  KEY `fk_EntryCategory_Entry_idx` (`EntryId`),
+  CONSTRAINT `fk_EntryCategory_Entry` FOREIGN KEY (`EntryId`) REFERENCES `Entry` (`Id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE `EntryAuthor` (
+  `Id` int(11) NOT NULL AUTO_INCREMENT,
+  `EntryId` int(11) NOT NULL,
+  `Name` varchar(256) DEFAULT NULL,
+  `Email` varchar(256) DEFAULT NULL,
+  `URI` varchar(1024) DEFAULT NULL,
+  PRIMARY KEY (`Id`),
+  KEY `fk_EntryAuthor_Entry_idx` (`EntryId`),
+  CONSTRAINT `fk_EntryAuthor_Entry` FOREIGN KEY (`EntryId`) REFERENCES `Entry` (`Id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE `EntryEnclosure` (
+  `Id` int(11) NOT NULL AUTO_INCREMENT,
+  `EntryId` int(11) NOT NULL,
+  `URL` varchar(1024) DEFAULT NULL,
+  `Type` varchar(256) DEFAULT NULL,
+  `Length` bigint(20) DEFAULT NULL,
+  PRIMARY KEY (`Id`),
+  KEY `fk_EntryEnclosure_Entry_idx` (`EntryId`),
+  CONSTRAINT `fk_EntryEnclosure_Entry` FOREIGN KEY (`EntryId`) REFERENCES `Entry` (`Id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+CREATE TABLE `EntryOriginal` (
+  `EntryId` int(11) NOT NULL,
+  `Summary` mediumtext,
+  `Encoded` mediumtext,
+  `Content` mediumtext,
+  PRIMARY KEY (`EntryId`),
+  CONSTRAINT `fk_EntryOriginal_Entry` FOREIGN KEY (`EntryId`) REFERENCES `Entry` (`Id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
 CREATE TABLE `Subscription` (
   `UserId` int(11) NOT NULL,
   `FeedId` int(11) NOT NULL,